@@ -0,0 +1,238 @@
+package main
+
+import (
+	"errors"
+	"io/fs"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/text/v2"
+	"github.com/hajimehoshi/ebiten/v2/vector"
+)
+
+// ACHIEVEMENTS_FILE is the file in the config directory that lists the
+// achievements unlocked so far, one per line
+const ACHIEVEMENTS_FILE = "achievements.txt"
+
+const (
+	BIG_EATER_FOOD = 50  // food to eat in one game for AchievementBigEater
+	TOAST_FRAMES   = 180 // how long an unlocked achievement is shown for
+)
+
+// Achievement is a goal that's unlocked once, and stays unlocked across games
+type Achievement int
+
+const (
+	// AchievementFirstWin is unlocked by winning a game
+	AchievementFirstWin Achievement = iota
+	// AchievementBigEater is unlocked by eating BIG_EATER_FOOD food in one game
+	AchievementBigEater
+	// AchievementFlawless is unlocked by winning a game without losing a life
+	AchievementFlawless
+	// AchievementUnderPar is unlocked by finishing a level with a ";par="
+	// header in less than its par time
+	AchievementUnderPar
+)
+
+// achievementList lists every achievement in the order they are written to
+// the achievements file
+var achievementList = [4]Achievement{AchievementFirstWin, AchievementBigEater, AchievementFlawless, AchievementUnderPar}
+
+// String returns the name the achievement is saved under
+func (achievement Achievement) String() string {
+	switch achievement {
+	case AchievementFirstWin:
+		return "first-win"
+	case AchievementBigEater:
+		return "big-eater"
+	case AchievementFlawless:
+		return "flawless"
+	case AchievementUnderPar:
+		return "under-par"
+	}
+	return "unknown"
+}
+
+// title returns how the achievement is named when it's unlocked
+func (achievement Achievement) title() string {
+	switch achievement {
+	case AchievementFirstWin:
+		return "first win"
+	case AchievementBigEater:
+		return "big eater"
+	case AchievementFlawless:
+		return "flawless"
+	case AchievementUnderPar:
+		return "under par"
+	}
+	return "unknown"
+}
+
+// Achievements records which achievements have been unlocked
+type Achievements struct {
+	unlocked [4]bool
+}
+
+// unlock marks the achievement as unlocked, and reports whether it wasn't
+// already
+func (achievements *Achievements) unlock(achievement Achievement) bool {
+	if achievements.unlocked[achievement] {
+		return false
+	}
+	achievements.unlocked[achievement] = true
+	return true
+}
+
+// String lists the names of the unlocked achievements, one per line
+func (achievements Achievements) String() string {
+	var builder strings.Builder
+	for _, achievement := range achievementList {
+		if achievements.unlocked[achievement] {
+			builder.WriteString(achievement.String() + "\n")
+		}
+	}
+	return builder.String()
+}
+
+// parseAchievements reads achievements in the format written by String.
+// names it doesn't know are ignored, so a file written by a newer version of
+// the game can still be read.
+func parseAchievements(content string) Achievements {
+	var achievements Achievements
+	for _, line := range strings.Split(content, "\n") {
+		for _, achievement := range achievementList {
+			if achievement.String() == strings.TrimSpace(line) {
+				achievements.unlock(achievement)
+			}
+		}
+	}
+	return achievements
+}
+
+// loadAchievements reads the unlocked achievements from the config directory.
+// a missing file means none have been unlocked yet.
+func loadAchievements() (Achievements, error) {
+	dir, err := configDir()
+	if err != nil {
+		return Achievements{}, err
+	}
+
+	content, err := os.ReadFile(filepath.Join(dir, ACHIEVEMENTS_FILE))
+	if errors.Is(err, fs.ErrNotExist) {
+		return Achievements{}, nil
+	}
+	if err != nil {
+		return Achievements{}, err
+	}
+	return parseAchievements(string(content)), nil
+}
+
+// saveAchievements writes the unlocked achievements to the config directory,
+// creating the directory if needed
+func saveAchievements(achievements Achievements) error {
+	dir, err := configDir()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(dir, ACHIEVEMENTS_FILE), []byte(achievements.String()), 0o644)
+}
+
+// earn records that the game in progress has met an achievement's goal. it's
+// called from the gameplay code, which knows nothing of what was unlocked in
+// earlier games, and Game.collectAchievements unlocks it for good.
+func (state *State) earn(achievement Achievement) {
+	for _, earned := range state.earned {
+		if earned == achievement {
+			return
+		}
+	}
+	state.earned = append(state.earned, achievement)
+}
+
+// earnWin records the achievements for winning the game
+func (state *State) earnWin() {
+	state.earn(AchievementFirstWin)
+	if state.deaths == 0 {
+		state.earn(AchievementFlawless)
+	}
+}
+
+// collectAchievements unlocks the achievements earned in the game in progress,
+// saving them and showing a toast for each one that's new. like the high
+// score, nothing is unlocked by versus, practice, or zen games, or replays.
+func (game *Game) collectAchievements() {
+	mode := game.state.config.mode
+	if mode == ModeVersus || mode == ModeZen || game.state.practice || game.replay != nil {
+		game.state.earned = nil
+		return
+	}
+	changed := false
+	for _, achievement := range game.state.earned {
+		if game.achievements.unlock(achievement) {
+			game.toasts = append(game.toasts, NewToast("achievement unlocked: "+achievement.title()))
+			changed = true
+		}
+	}
+	game.state.earned = nil
+	if changed {
+		if err := saveAchievements(game.achievements); err != nil {
+			log.Printf("saving achievements: %v", err)
+		}
+	}
+}
+
+// Toast is a message shown briefly at the top of the screen
+type Toast struct {
+	text string
+	// framesLeft counts down from TOAST_FRAMES to 0, when the toast is removed
+	framesLeft int
+}
+
+func NewToast(text string) Toast {
+	return Toast{text: text, framesLeft: TOAST_FRAMES}
+}
+
+// updateToasts ages the toast being shown by a frame, and moves on to the next
+// one once it has finished. toasts are shown one at a time, in the order they
+// were queued.
+func (game *Game) updateToasts() {
+	if len(game.toasts) == 0 {
+		return
+	}
+	game.toasts[0].framesLeft -= 1
+	if game.toasts[0].framesLeft <= 0 {
+		game.toasts = game.toasts.removeAt(0)
+	}
+}
+
+// drawToast draws the first queued toast on a bar across the top of the
+// screen, fading out over its last second
+func (game *Game) drawToast(screen *ebiten.Image) {
+	if len(game.toasts) == 0 {
+		return
+	}
+	toast := game.toasts[0]
+	alpha := float32(1)
+	if toast.framesLeft < FPS {
+		alpha = float32(toast.framesLeft) / FPS
+	}
+
+	width, height := text.Measure(toast.text, &game.font.tiny, 0)
+	left := (float32(game.layout.width) - float32(width)) / 2
+	// colors are premultiplied, so fading scales every channel
+	bar := dimColor(game.config.palette.overlay, float64(alpha))
+	bar.A = uint8(float32(bar.A) * alpha)
+	vector.DrawFilledRect(screen, left-10, 95, float32(width)+20, float32(height)+10, bar, true)
+
+	op := &text.DrawOptions{}
+	op.GeoM.Translate(float64(left), 100)
+	op.ColorScale.ScaleWithColor(game.config.palette.menuSelection)
+	op.ColorScale.ScaleAlpha(alpha)
+	text.Draw(screen, toast.text, &game.font.tiny, op)
+}
@@ -0,0 +1,89 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+// earnedOnce fails the test unless want was earned exactly once in state
+func earnedOnce(t *testing.T, state *State, want Achievement) {
+	t.Helper()
+	count := 0
+	for _, achievement := range state.earned {
+		if achievement == want {
+			count++
+		}
+	}
+	if count != 1 {
+		t.Errorf("%v earned %d times in %v, want once", want, count, state.earned)
+	}
+}
+
+// playUntilOver steps the state straight ahead until the game is won or lost
+func playUntilOver(state *State, direction Vec2) {
+	state.Step(direction)
+	for i := 0; i < 1000 && (state.status == StatusPlaying || state.status == StatusDying); i++ {
+		state.Step(Vec2{})
+	}
+}
+
+func TestAchievementsFlawlessWin(t *testing.T) {
+	state := newTestState(t, ";par=10\n######\n#SF.E#\n######\n")
+	playUntilOver(&state, Vec2{x: 1, y: 0})
+	if state.status != StatusWon {
+		t.Fatalf("status = %v, want won", state.status)
+	}
+	earnedOnce(t, &state, AchievementFirstWin)
+	earnedOnce(t, &state, AchievementFlawless)
+	earnedOnce(t, &state, AchievementUnderPar)
+}
+
+func TestAchievementsWinAfterDying(t *testing.T) {
+	state := newTestState(t, "######\n#SF.E#\n#....#\n######\n")
+	state.deaths = 1
+	playUntilOver(&state, Vec2{x: 1, y: 0})
+	if state.status != StatusWon {
+		t.Fatalf("status = %v, want won", state.status)
+	}
+	earnedOnce(t, &state, AchievementFirstWin)
+	if !reflect.DeepEqual(state.earned, Slice[Achievement]{AchievementFirstWin}) {
+		t.Errorf("earned %v after a death and no par, want only %v", state.earned, AchievementFirstWin)
+	}
+}
+
+func TestAchievementsBigEater(t *testing.T) {
+	state := newTestState(t, "########\n#SFFF.E#\n########\n")
+	state.foodEaten = BIG_EATER_FOOD - 2
+	stepUntilMoved(t, &state, Vec2{x: 1, y: 0})
+	if len(state.earned) != 0 {
+		t.Errorf("earned %v one food short, want nothing", state.earned)
+	}
+	stepUntilMoved(t, &state, Vec2{})
+	stepUntilMoved(t, &state, Vec2{})
+	earnedOnce(t, &state, AchievementBigEater)
+}
+
+func TestAchievementsLosing(t *testing.T) {
+	state := newTestState(t, "######\n#SF.E#\n######\n")
+	state.lives = 1
+	playUntilOver(&state, Vec2{x: -1, y: 0})
+	if state.status != StatusLost {
+		t.Fatalf("status = %v, want lost", state.status)
+	}
+	if len(state.earned) != 0 {
+		t.Errorf("earned %v by losing, want nothing", state.earned)
+	}
+}
+
+func TestUnlockOnce(t *testing.T) {
+	var achievements Achievements
+	if !achievements.unlock(AchievementFlawless) {
+		t.Error("first unlock reported it was already unlocked")
+	}
+	if achievements.unlock(AchievementFlawless) {
+		t.Error("second unlock reported it was new")
+	}
+	if got := parseAchievements(achievements.String()); got != achievements {
+		t.Errorf("parsed %v back from %q, want %v", got.unlocked, achievements.String(), achievements.unlocked)
+	}
+}
@@ -0,0 +1,131 @@
+package main
+
+import (
+	"bytes"
+	"io"
+	"log"
+
+	"github.com/hajimehoshi/ebiten/v2/audio"
+	"github.com/hajimehoshi/ebiten/v2/audio/wav"
+)
+
+const (
+	SAMPLE_RATE  = 44100
+	MUSIC_VOLUME = 0.5
+)
+
+// global sounds. it stays package-level because it owns the audio context, and
+// ebitengine only allows one to be created per process.
+var sounds Sounds = NewSounds()
+
+// Sound is one of the sound effects the game logic can ask for. State only
+// queues them, so it never touches the audio context and can run without one.
+type Sound int
+
+const (
+	SoundEat Sound = iota
+	SoundDie
+	SoundWin
+)
+
+type Sounds struct {
+	context *audio.Context
+	music   *audio.Player
+	eat     []byte
+	die     []byte
+	win     []byte
+	muted   bool
+}
+
+// NewSounds creates a new Sounds struct by creating the audio context and
+// loading the music and sound effects from the assets folder
+func NewSounds() Sounds {
+	context := audio.NewContext(SAMPLE_RATE)
+
+	musicStream, err := wav.DecodeWithSampleRate(SAMPLE_RATE, bytes.NewReader(readAsset("assets/music.wav")))
+	if err != nil {
+		log.Fatal(err)
+	}
+	music, err := context.NewPlayer(audio.NewInfiniteLoop(musicStream, musicStream.Length()))
+	if err != nil {
+		log.Fatal(err)
+	}
+	music.SetVolume(MUSIC_VOLUME)
+
+	return Sounds{
+		context: context,
+		music:   music,
+		eat:     loadEffect("assets/eat.wav"),
+		die:     loadEffect("assets/die.wav"),
+		win:     loadEffect("assets/win.wav"),
+		muted:   false,
+	}
+}
+
+// readAsset returns the contents of the named file in the assets folder
+func readAsset(name string) []byte {
+	content, err := assets.ReadFile(name)
+	if err != nil {
+		log.Fatal(err)
+	}
+	return content
+}
+
+// loadEffect decodes a wav sound effect from the assets folder into raw PCM
+// bytes, so it can be played any number of times without decoding again
+func loadEffect(name string) []byte {
+	stream, err := wav.DecodeWithSampleRate(SAMPLE_RATE, bytes.NewReader(readAsset(name)))
+	if err != nil {
+		log.Fatal(err)
+	}
+	pcm, err := io.ReadAll(stream)
+	if err != nil {
+		log.Fatal(err)
+	}
+	return pcm
+}
+
+// play plays a one-shot sound effect unless the game is muted
+func (sounds *Sounds) play(effect []byte) {
+	if sounds.muted {
+		return
+	}
+	sounds.context.NewPlayerFromBytes(effect).Play()
+}
+
+// updateMusic keeps the background music looping while the game is being
+// played and pauses it everywhere else or when muted
+func (sounds *Sounds) updateMusic(status Status) {
+	if status == StatusPlaying && !sounds.muted {
+		if !sounds.music.IsPlaying() {
+			sounds.music.Play()
+		}
+		return
+	}
+	sounds.music.Pause()
+}
+
+// effect returns the decoded sound effect for the given sound
+func (sounds *Sounds) effect(sound Sound) []byte {
+	switch sound {
+	case SoundDie:
+		return sounds.die
+	case SoundWin:
+		return sounds.win
+	}
+	return sounds.eat
+}
+
+// playSound queues a sound effect to be played once Game has finished updating
+// the state
+func (state *State) playSound(sound Sound) {
+	state.pendingSounds = append(state.pendingSounds, sound)
+}
+
+// playSounds plays the sound effects the state has queued since the last frame
+func (game *Game) playSounds() {
+	for _, sound := range game.state.pendingSounds {
+		sounds.play(sounds.effect(sound))
+	}
+	game.state.pendingSounds = nil
+}
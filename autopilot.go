@@ -0,0 +1,233 @@
+package main
+
+import "container/heap"
+
+// pathNode is a cell waiting to be expanded by findPath, ordered by its
+// estimated total cost
+type pathNode struct {
+	position Vec2
+	cost     int // steps taken from the start
+	estimate int // cost plus the heuristic distance left
+}
+
+// pathQueue is a min-heap of pathNodes for container/heap
+type pathQueue Slice[pathNode]
+
+func (queue pathQueue) Len() int { return len(queue) }
+func (queue pathQueue) Less(i, j int) bool {
+	return queue[i].estimate < queue[j].estimate
+}
+func (queue pathQueue) Swap(i, j int)  { queue[i], queue[j] = queue[j], queue[i] }
+func (queue *pathQueue) Push(node any) { *queue = append(*queue, node.(pathNode)) }
+func (queue *pathQueue) Pop() any {
+	old := *queue
+	node := old[len(old)-1]
+	*queue = old[:len(old)-1]
+	return node
+}
+
+// findPath returns the cells of the shortest path from start to target, not
+// including start, found with A* over the level's wrapping grid. cells in
+// blocked are treated as walls, and portals and arrow tiles are followed the
+// same as the snake would. it returns nil when target can't be reached.
+func (level *Level) findPath(start Vec2, target Vec2, blocked map[Vec2]bool) Slice[Vec2] {
+	// a portal can shorten a path past what wrappedDistance allows for, which
+	// would make A* miss the shortest route, so levels with portals fall back
+	// to a plain shortest-first search
+	heuristic := level.wrappedDistance
+	if len(level.portals) > 0 {
+		heuristic = func(Vec2, Vec2) int { return 0 }
+	}
+
+	cameFrom := map[Vec2]Vec2{}
+	costs := map[Vec2]int{start: 0}
+	queue := &pathQueue{{position: start, cost: 0, estimate: heuristic(start, target)}}
+	for queue.Len() > 0 {
+		current := heap.Pop(queue).(pathNode)
+		if current.position == target {
+			path := NewSlice[Vec2]()
+			for position := target; position != start; position = cameFrom[position] {
+				path = append(path, position)
+			}
+			for i, j := 0, len(path)-1; i < j; i, j = i+1, j-1 {
+				path[i], path[j] = path[j], path[i]
+			}
+			return path
+		}
+		if current.cost > costs[current.position] {
+			continue // a shorter way here was already expanded
+		}
+
+		for _, direction := range directions {
+			if forced, ok := level.arrows[current.position]; ok && direction != forced {
+				continue
+			}
+			next := level.step(current.position, direction)
+			if level.walls[next.y][next.x] || blocked[next] {
+				continue
+			}
+			cost := current.cost + 1
+			if previous, ok := costs[next]; ok && previous <= cost {
+				continue
+			}
+			costs[next] = cost
+			cameFrom[next] = current.position
+			heap.Push(queue, pathNode{position: next, cost: cost, estimate: cost + heuristic(next, target)})
+		}
+	}
+	return nil
+}
+
+// AUTOPILOT_LOOKAHEAD is how many cells of a planned path must stay clear for
+// the autopilot to keep following it instead of planning a new one
+const AUTOPILOT_LOOKAHEAD = 3
+
+// autopilotObstacles returns the cells the autopilot plans around. avoided
+// holds everything it would rather not touch: the snakes' bodies, and ghosts
+// along with the cells next to them unless powered up. required holds only
+// the cell behind the head, which the snake can't turn back onto at all.
+func autopilotObstacles(state *State) (avoided map[Vec2]bool, required map[Vec2]bool) {
+	snake := &state.snakes[0]
+	head := snake.getHead()
+
+	required = map[Vec2]bool{}
+	if snake.prevDirection != (Vec2{}) {
+		required[state.level.step(head, Vec2{x: -snake.prevDirection.x, y: -snake.prevDirection.y})] = true
+	}
+
+	avoided = map[Vec2]bool{}
+	for position := range required {
+		avoided[position] = true
+	}
+	// collisions are checked before the tail moves up, so the tail counts too
+	for _, s := range state.snakes {
+		for _, segment := range s.body {
+			avoided[segment] = true
+		}
+	}
+	// ghosts move right after the snake, so the cells next to them are as
+	// dangerous as the ones they're on
+	if state.powerUpTimer == 0 {
+		for _, ghost := range state.level.ghosts {
+			avoided[ghost.position] = true
+			for _, direction := range directions {
+				avoided[state.level.step(ghost.position, direction)] = true
+			}
+		}
+	}
+	delete(avoided, head)
+	return avoided, required
+}
+
+// autopilotTargets returns the cells the autopilot heads for, in groups tried
+// in order: the food, then the exit for when no food can be reached. endless
+// games have no exit to head for.
+func autopilotTargets(state *State) Slice[Slice[Vec2]] {
+	groups := NewSlice(state.level.scoringFoods())
+	if state.config.mode != ModeEndless {
+		groups = append(groups, NewSlice(state.level.exit))
+	}
+	return groups
+}
+
+// autopilotPath returns the route for player one's snake to follow. the
+// previous route is kept, less any step already taken, while it still leads
+// to a target and its next few cells are clear. otherwise a new one is planned
+// to the nearest food, or to the exit once the food is gone or can't be
+// reached. planning first keeps clear of bodies and ghosts and only runs
+// through them when there is no other way. with nowhere to go it steps onto
+// any free neighbor to stay alive, and returns nil if there is none.
+func autopilotPath(state *State, previous Slice[Vec2]) Slice[Vec2] {
+	head := state.snakes[0].getHead()
+	avoided, required := autopilotObstacles(state)
+	targets := autopilotTargets(state)
+
+	// sticking to a plan stops the snake pacing back and forth between two
+	// routes of nearly the same length as it turns and the obstacles move
+	if len(previous) > 0 && previous[0] == head {
+		previous = previous[1:]
+	}
+	if len(previous) > 0 && autopilotDirection(state, previous) != (Vec2{}) {
+		destination := previous[len(previous)-1]
+		reachesTarget := false
+		for _, group := range targets {
+			for _, target := range group {
+				if target == destination {
+					reachesTarget = true
+				}
+			}
+		}
+		clear := true
+		for i := 0; i < len(previous) && i < AUTOPILOT_LOOKAHEAD; i++ {
+			if avoided[previous[i]] {
+				clear = false
+			}
+		}
+		if reachesTarget && clear {
+			return previous
+		}
+	}
+
+	// the nearest target is picked by the length of its path rather than its
+	// distance, which would often pick a target on the far side of a wall
+	for _, blocked := range [2]map[Vec2]bool{avoided, required} {
+		for _, group := range targets {
+			var shortest Slice[Vec2]
+			for _, target := range group {
+				path := state.level.findPath(head, target, blocked)
+				if path != nil && (shortest == nil || len(path) < len(shortest)) {
+					shortest = path
+				}
+			}
+			if shortest != nil {
+				return shortest
+			}
+		}
+	}
+	for _, blocked := range [2]map[Vec2]bool{avoided, required} {
+		for _, direction := range directions {
+			next := state.level.step(head, direction)
+			if !state.level.walls[next.y][next.x] && !blocked[next] && !state.level.crossesEdge(head, direction) {
+				return NewSlice(next)
+			}
+		}
+	}
+	return nil
+}
+
+// autopilotDirection returns the direction of the first step along path from
+// the head of player one's snake, or zero if there is no path or it doesn't
+// start next to the head
+func autopilotDirection(state *State, path Slice[Vec2]) Vec2 {
+	if len(path) == 0 {
+		return Vec2{}
+	}
+	head := state.snakes[0].getHead()
+	for _, direction := range directions {
+		if state.level.step(head, direction) == path[0] {
+			return direction
+		}
+	}
+	return Vec2{}
+}
+
+// autopilotInput returns the input mask that steers player one's snake along
+// the autopilot's path, in the same form as readInput. the path is only
+// revised on the frame the snake is about to move, and the turn is given
+// then, so it never queues up behind a stale one. the path is kept on Game so
+// it can be drawn.
+func (game *Game) autopilotInput() int {
+	snake := &game.state.snakes[0]
+	if game.autopilotPath != nil && (len(snake.inputQueue) > 0 || snake.framesSinceLastMove+1 < snake.effectiveMoveInterval(&game.state)) {
+		return 0
+	}
+
+	game.autopilotPath = autopilotPath(&game.state, game.autopilotPath)
+	direction := autopilotDirection(&game.state, game.autopilotPath)
+	for i := range directions {
+		if directions[i] == direction {
+			return 1 << i
+		}
+	}
+	return 0
+}
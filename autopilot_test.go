@@ -0,0 +1,71 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestFindPath(t *testing.T) {
+	tests := []struct {
+		name   string
+		level  string
+		start  Vec2
+		target Vec2
+		want   Slice[Vec2]
+	}{
+		{
+			name:   "through the wrap",
+			level:  "#########\n.S.#.F.E.\n#########\n",
+			start:  Vec2{x: 1, y: 1},
+			target: Vec2{x: 5, y: 1},
+			want:   NewSlice(Vec2{x: 0, y: 1}, Vec2{x: 8, y: 1}, Vec2{x: 7, y: 1}, Vec2{x: 6, y: 1}, Vec2{x: 5, y: 1}),
+		},
+		{
+			name:   "through a portal",
+			level:  "############\n#S.a###aF.E#\n#..........#\n############\n",
+			start:  Vec2{x: 1, y: 1},
+			target: Vec2{x: 8, y: 1},
+			want:   NewSlice(Vec2{x: 2, y: 1}, Vec2{x: 7, y: 1}, Vec2{x: 8, y: 1}),
+		},
+		{
+			name:   "unreachable",
+			level:  "#######\n#SFE#.#\n#######\n",
+			start:  Vec2{x: 1, y: 1},
+			target: Vec2{x: 5, y: 1},
+			want:   nil,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			level, err := parseLevel(1, test.level)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := level.findPath(test.start, test.target, nil); !reflect.DeepEqual(got, test.want) {
+				t.Errorf("findPath = %v, want %v", got, test.want)
+			}
+		})
+	}
+}
+
+func TestAutopilotFinishesLevel(t *testing.T) {
+	state := newTestState(t, "#######\n#S..F.#\n#.###.#\n#F...E#\n#######\n")
+	var path Slice[Vec2]
+	for i := 0; i < 2000 && state.status != StatusWon && state.status != StatusLost; i++ {
+		// like autopilotInput, the route is only planned as the snake is
+		// about to move
+		direction := Vec2{}
+		snake := &state.snakes[0]
+		if path == nil || snake.framesSinceLastMove+1 >= snake.effectiveMoveInterval(&state) {
+			path = autopilotPath(&state, path)
+			direction = autopilotDirection(&state, path)
+		}
+		state.Step(direction)
+	}
+	if state.status != StatusWon {
+		t.Errorf("status = %v, want the autopilot to have won", state.status)
+	}
+	if state.lives != DefaultConfig().startingLives {
+		t.Errorf("lives = %d, want none lost", state.lives)
+	}
+}
@@ -0,0 +1,84 @@
+package main
+
+import (
+	"image"
+	"image/color"
+
+	"github.com/hajimehoshi/ebiten/v2"
+)
+
+var (
+	// whiteImage is the source every batched triangle samples from, so the
+	// vertex colors alone decide what is drawn. the center pixel is used so
+	// the edges never bleed in, the same as the vector package does.
+	whiteImage    = ebiten.NewImage(3, 3)
+	whiteSubImage = whiteImage.SubImage(image.Rect(1, 1, 2, 2)).(*ebiten.Image)
+)
+
+func init() {
+	whiteImage.Fill(color.White)
+}
+
+// CellBatch collects filled cells by color so every cell of one color is drawn
+// with a single DrawTriangles call, instead of one vector.DrawFilledRect call
+// per cell
+type CellBatch struct {
+	// colors holds each color in the order it was first added, so groups are
+	// drawn in a stable order
+	colors   Slice[color.RGBA]
+	vertices map[color.RGBA]Slice[ebiten.Vertex]
+	indices  map[color.RGBA]Slice[uint16]
+}
+
+func NewCellBatch() CellBatch {
+	return CellBatch{
+		colors:   NewSlice[color.RGBA](),
+		vertices: map[color.RGBA]Slice[ebiten.Vertex]{},
+		indices:  map[color.RGBA]Slice[uint16]{},
+	}
+}
+
+// add queues the cell at p to be filled with c, covering the same pixels as
+// drawCell
+func (batch *CellBatch) add(state *State, p Vec2, c color.RGBA) {
+	vertices, ok := batch.vertices[c]
+	if !ok {
+		batch.colors = append(batch.colors, c)
+	}
+
+	size := state.layout.cellSize
+	offset := viewportOffset(state, p)
+	left, top := float32(offset.x*size), float32(offset.y*size)
+	right, bottom := left+float32(size-1), top+float32(size-1)
+
+	// colors are passed through premultiplied, matching drawVerticesForUtil in
+	// the vector package
+	r, g, b, a := c.RGBA()
+	base := uint16(len(vertices))
+	for _, corner := range [4][2]float32{{left, top}, {left, bottom}, {right, bottom}, {right, top}} {
+		vertices = append(vertices, ebiten.Vertex{
+			DstX:   corner[0],
+			DstY:   corner[1],
+			SrcX:   1,
+			SrcY:   1,
+			ColorR: float32(r) / 0xffff,
+			ColorG: float32(g) / 0xffff,
+			ColorB: float32(b) / 0xffff,
+			ColorA: float32(a) / 0xffff,
+		})
+	}
+	batch.vertices[c] = vertices
+	batch.indices[c] = append(batch.indices[c], base, base+1, base+2, base, base+2, base+3)
+}
+
+// draw fills every queued cell, one DrawTriangles call per color. only
+// visible cells are queued, so a group stays far below the 16 bit index limit
+// of DrawTriangles.
+func (batch *CellBatch) draw(screen *ebiten.Image) {
+	op := &ebiten.DrawTrianglesOptions{}
+	op.ColorScaleMode = ebiten.ColorScaleModePremultipliedAlpha
+	op.AntiAlias = true
+	for _, c := range batch.colors {
+		screen.DrawTriangles(batch.vertices[c], batch.indices[c], whiteSubImage, op)
+	}
+}
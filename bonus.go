@@ -0,0 +1,64 @@
+package main
+
+import (
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/vector"
+)
+
+const (
+	BONUS_INTERVAL = 20 * FPS // frames between one bonus fruit and the next
+	BONUS_LIFETIME = 8 * FPS  // frames a bonus fruit waits to be eaten
+	BONUS_POINTS   = 10
+)
+
+// updateBonus counts down the bonus fruit on the level until it disappears,
+// or while there is none, counts down to the next one and places it on a
+// random empty cell
+func updateBonus(state *State) {
+	if state.bonusFrames > 0 {
+		state.bonusFrames -= 1
+		return
+	}
+	state.bonusTimer -= 1
+	if state.bonusTimer > 0 {
+		return
+	}
+	state.bonusTimer = BONUS_INTERVAL
+	if position, ok := randomEmptyCell(state); ok {
+		state.bonus = position
+		state.bonusFrames = BONUS_LIFETIME
+	}
+}
+
+// eatBonus scores the bonus fruit when the snake's head is on it. unlike food
+// it doesn't make the snake grow.
+func (snake *Snake) eatBonus(state *State) {
+	if state.bonusFrames == 0 || snake.getHead() != state.bonus {
+		return
+	}
+	state.bonusFrames = 0
+	state.addScore(snake, BONUS_POINTS)
+	state.popups = append(state.popups, NewPopup(state.bonus, BONUS_POINTS))
+	state.playSound(SoundEat)
+}
+
+// drawBonus draws the bonus fruit as a pair of cherries, blinking for the last
+// frames before it disappears the same way a power-up does
+func drawBonus(screen *ebiten.Image, state *State) {
+	if state.bonusFrames == 0 || !isVisible(state, state.bonus) {
+		return
+	}
+	if state.bonusFrames <= POWER_UP_WARNING_FRAMES && state.bonusFrames%10 < 5 {
+		return
+	}
+	size := float32(state.layout.cellSize)
+	offset := viewportOffset(state, state.bonus)
+	left := float32(offset.x) * size
+	top := float32(offset.y) * size
+	stem := state.config.palette.rival
+	fruit := state.config.palette.valuableFood
+	vector.StrokeLine(screen, left+size*0.3, top+size*0.6, left+size*0.6, top+size*0.15, 2, stem, true)
+	vector.StrokeLine(screen, left+size*0.7, top+size*0.65, left+size*0.6, top+size*0.15, 2, stem, true)
+	vector.DrawFilledCircle(screen, left+size*0.3, top+size*0.7, size*0.2, fruit, true)
+	vector.DrawFilledCircle(screen, left+size*0.7, top+size*0.75, size*0.2, fruit, true)
+}
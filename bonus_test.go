@@ -0,0 +1,34 @@
+package main
+
+import "testing"
+
+func TestBonusTiming(t *testing.T) {
+	state := newTestState(t, "#######\n#S....#\n#.....#\n#F...E#\n#######\n")
+	for cycle := 0; cycle < 2; cycle++ {
+		for i := 1; i < BONUS_INTERVAL; i++ {
+			updateBonus(&state)
+			if state.bonusFrames != 0 {
+				t.Fatalf("cycle %d: bonus fruit out after %d frames, want it after %d", cycle, i, BONUS_INTERVAL)
+			}
+		}
+		updateBonus(&state)
+		if state.bonusFrames != BONUS_LIFETIME {
+			t.Fatalf("cycle %d: bonus frames = %d after %d frames, want %d", cycle, state.bonusFrames, BONUS_INTERVAL, BONUS_LIFETIME)
+		}
+		bonus := state.bonus
+		if state.level.walls[bonus.y][bonus.x] || bonus == state.level.exit || state.level.foodAt(bonus) != -1 || isOccupied(&state, bonus) {
+			t.Errorf("cycle %d: bonus fruit placed on %v, want an empty cell", cycle, bonus)
+		}
+
+		for i := 1; i < BONUS_LIFETIME; i++ {
+			updateBonus(&state)
+		}
+		if state.bonusFrames == 0 {
+			t.Fatalf("cycle %d: bonus fruit gone a frame early", cycle)
+		}
+		updateBonus(&state)
+		if state.bonusFrames != 0 {
+			t.Fatalf("cycle %d: bonus frames = %d after %d frames, want it expired", cycle, state.bonusFrames, BONUS_LIFETIME)
+		}
+	}
+}
@@ -0,0 +1,29 @@
+package main
+
+// Clock converts the ticks ebitengine calls Update on into game frames. the
+// game logic counts everything in frames at FPS, so at any other tick rate a
+// tick can be worth less or more than a frame. whole frames are handed out as
+// they build up, and the remainder is carried to the next tick, which keeps
+// the snake moving at the same real-world speed whatever the tick rate.
+//
+// the configured rate is used rather than the measured one, so a game runs
+// the same frames, and a recording replays the same way, on any machine.
+type Clock struct {
+	// pending is the time carried over from earlier ticks, in units of
+	// 1/(FPS*tps) seconds so it adds up exactly
+	pending int
+}
+
+// frames advances the clock by one tick at tps ticks per second, and returns
+// the number of whole game frames that have now passed. a rate that isn't
+// positive, such as when ticks are synced to the display, counts one frame a
+// tick.
+func (clock *Clock) frames(tps int) int {
+	if tps <= 0 {
+		return 1
+	}
+	clock.pending += FPS
+	frames := clock.pending / tps
+	clock.pending %= tps
+	return frames
+}
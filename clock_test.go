@@ -0,0 +1,32 @@
+package main
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestClockFrames(t *testing.T) {
+	for _, tps := range []int{30, 50, 60, 75, 120, 144, 240} {
+		t.Run(fmt.Sprint(tps), func(t *testing.T) {
+			var clock Clock
+			frames := 0
+			for second := 1; second <= 5; second++ {
+				for tick := 0; tick < tps; tick++ {
+					frames += clock.frames(tps)
+				}
+				if frames != second*FPS {
+					t.Fatalf("%d frames after %d seconds of ticks, want %d", frames, second, second*FPS)
+				}
+			}
+		})
+	}
+}
+
+func TestClockFramesUnsetRate(t *testing.T) {
+	var clock Clock
+	for _, tps := range []int{0, -1} {
+		if frames := clock.frames(tps); frames != 1 {
+			t.Errorf("frames(%d) = %d, want 1", tps, frames)
+		}
+	}
+}
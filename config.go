@@ -0,0 +1,173 @@
+package main
+
+import (
+	"fmt"
+	"io/fs"
+)
+
+// Mode is the kind of game started from the menu
+type Mode int
+
+const (
+	// ModeCampaign plays through the levels alone
+	ModeCampaign Mode = iota
+	// ModeVersus has two players race each other to the exit of one level
+	ModeVersus
+	// ModeEndless has no exit, and food respawns forever on one level
+	ModeEndless
+	// ModeDaily plays one generated maze, seeded from the date so everyone
+	// gets the same one each day
+	ModeDaily
+	// ModePractice plays through the levels with unlimited retries and no
+	// score, for learning them
+	ModePractice
+	// ModeZen plays through the levels with no ghosts, no time limits, and no
+	// way to die, since the snake stops at anything it would crash into
+	ModeZen
+)
+
+func (mode Mode) String() string {
+	switch mode {
+	case ModeCampaign:
+		return "1 player"
+	case ModeVersus:
+		return "2 player versus"
+	case ModeEndless:
+		return "endless"
+	case ModeDaily:
+		return "daily challenge"
+	case ModePractice:
+		return "practice"
+	case ModeZen:
+		return "zen"
+	}
+	return "unknown"
+}
+
+// next returns the mode after this one, wrapping back to the first
+func (mode Mode) next() Mode {
+	return (mode + 1) % (ModeZen + 1)
+}
+
+// Config holds the tunable settings for a game. a copy is stored on State when
+// a new game starts, so gameplay reads its settings from there instead of
+// package constants.
+type Config struct {
+	// screenWidth and screenHeight are the starting window size in pixels,
+	// and gridSize is the size of a level cell at that size
+	screenWidth       int
+	screenHeight      int
+	gridSize          int
+	powerUpTime       int // frames a power-up lasts
+	slowMotionTime    int // frames slow motion lasts
+	moveInterval      int // frames between snake steps
+	minMoveInterval   int // fastest the snake can get
+	speedupScore      int // points needed for each speed increase
+	speedupLength     int // segments of growth for each speed increase, or 0 for none
+	startingLives     int
+	ghostMoveInterval int // frames between ghost steps
+	ghostBonus        int // points for eating a ghost while powered up
+	comboWindow       int // frames after eating in which the next food combos
+	maxCombo          int // highest score multiplier a combo can reach
+	trailLength       int // cells of fading trail drawn behind the snake
+	suddenDeathTime   int // frames into a level before walls close in, or 0 for never
+	soundEnabled      bool
+	// generate replaces the level files with procedurally generated mazes,
+	// seeded from seed and the level id
+	generate bool
+	// seed drives every random decision, so a game can be reproduced
+	seed int
+	// levels holds the level-N.txt files to play, at its root, and levelsDir
+	// is the directory they were loaded from, or empty for the built in ones
+	levels     fs.FS
+	levelsDir  string
+	mode       Mode
+	difficulty Difficulty
+	// startLevel is the id of the level selected in the menu at startup, or 0
+	// for the first level
+	startLevel int
+	// snakeStyle is how snakes' bodies are drawn
+	snakeStyle SnakeStyle
+	// debug enables tools for testing levels that have no place in normal
+	// play, like noclip
+	debug bool
+
+	palette Palette
+	keys    KeyBindings
+}
+
+// DefaultConfig returns the configuration the game ships with
+func DefaultConfig() Config {
+	return Config{
+		screenWidth:       SCREEN_WIDTH,
+		screenHeight:      SCREEN_HEIGHT,
+		gridSize:          20,
+		powerUpTime:       300, // 5 seconds @ 60fps
+		slowMotionTime:    240,
+		moveInterval:      10,
+		minMoveInterval:   4,
+		speedupScore:      5,
+		speedupLength:     8,
+		startingLives:     3,
+		ghostMoveInterval: 20,
+		ghostBonus:        5,
+		comboWindow:       90,
+		maxCombo:          3,
+		trailLength:       0,
+		suddenDeathTime:   0,
+		soundEnabled:      true,
+		generate:          false,
+		seed:              0,
+		mode:              ModeCampaign,
+		difficulty:        DifficultyNormal,
+		levels:            embeddedLevels(),
+
+		palette: DefaultPalette(),
+		keys:    DefaultKeyBindings(),
+	}
+}
+
+// moveIntervalForScore returns how many frames the snake waits between steps
+// for the given score. the snake speeds up every speedupScore points until it
+// reaches minMoveInterval.
+func (config Config) moveIntervalForScore(score int) int {
+	interval := config.moveInterval - score/config.speedupScore
+	if interval < config.minMoveInterval {
+		return config.minMoveInterval
+	}
+	return interval
+}
+
+// moveIntervalForLength returns interval shortened by a frame for every
+// speedupLength segments the snake has grown past its head, so longer snakes
+// move faster. it stops at minMoveInterval, and an interval already below that
+// is left as it is.
+func (config Config) moveIntervalForLength(interval int, length int) int {
+	if config.speedupLength <= 0 || interval <= config.minMoveInterval {
+		return interval
+	}
+	interval -= (length - 1) / config.speedupLength
+	if interval < config.minMoveInterval {
+		return config.minMoveInterval
+	}
+	return interval
+}
+
+// embeddedLevels returns the level files built into the game
+func embeddedLevels() fs.FS {
+	// fs.Sub only fails for invalid paths, and "assets" is a valid one
+	levels, _ := fs.Sub(assets, "assets")
+	return levels
+}
+
+// validateScreen checks that the screen size and grid size are positive and
+// that the grid divides the screen into whole cells
+func (config Config) validateScreen() error {
+	if config.screenWidth <= 0 || config.screenHeight <= 0 || config.gridSize <= 0 {
+		return fmt.Errorf("invalid screen: width %d, height %d, and grid %d must all be positive", config.screenWidth, config.screenHeight, config.gridSize)
+	}
+	if config.screenWidth%config.gridSize != 0 || config.screenHeight%config.gridSize != 0 {
+		return fmt.Errorf("invalid screen: grid %d doesn't divide %dx%d into whole cells", config.gridSize, config.screenWidth, config.screenHeight)
+	}
+	return nil
+}
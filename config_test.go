@@ -0,0 +1,54 @@
+package main
+
+import "testing"
+
+func TestMoveIntervalForLength(t *testing.T) {
+	config := DefaultConfig()
+	config.moveInterval = 10
+	config.minMoveInterval = 4
+	config.speedupLength = 8
+
+	tests := []struct {
+		interval int
+		length   int
+		want     int
+	}{
+		{10, 1, 10},
+		{10, 8, 10},
+		{10, 9, 9},
+		{10, 17, 8},
+		{10, 41, 5},
+		{10, 49, 4},
+		{10, 1000, 4},
+		{4, 100, 4},
+		{3, 100, 3},
+	}
+	for _, test := range tests {
+		if got := config.moveIntervalForLength(test.interval, test.length); got != test.want {
+			t.Errorf("moveIntervalForLength(%d, %d) = %d, want %d", test.interval, test.length, got, test.want)
+		}
+	}
+
+	previous := config.moveInterval
+	for length := 1; length <= 200; length++ {
+		interval := config.moveIntervalForLength(config.moveInterval, length)
+		if interval > previous {
+			t.Fatalf("interval went up from %d to %d at length %d", previous, interval, length)
+		}
+		if interval < config.minMoveInterval {
+			t.Fatalf("interval = %d at length %d, below the floor of %d", interval, length, config.minMoveInterval)
+		}
+		previous = interval
+	}
+	if previous != config.minMoveInterval {
+		t.Errorf("interval = %d at length 200, want the floor of %d", previous, config.minMoveInterval)
+	}
+}
+
+func TestMoveIntervalForLengthDisabled(t *testing.T) {
+	config := DefaultConfig()
+	config.speedupLength = 0
+	if got := config.moveIntervalForLength(10, 100); got != 10 {
+		t.Errorf("moveIntervalForLength(10, 100) = %d with speedupLength 0, want 10", got)
+	}
+}
@@ -0,0 +1,121 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strconv"
+)
+
+// CONFIG_FILE is the file in the config directory the player's preferences are
+// read from at startup and saved to from the options screen. it's written with
+// the defaults the first time the game runs, so there is something to edit.
+const CONFIG_FILE = "config.json"
+
+// ConfigFile is the part of Config kept in the config file, in the form it's
+// written as JSON
+type ConfigFile struct {
+	Difficulty string `json:"difficulty"`
+	Sound      bool   `json:"sound"`
+	Palette    string `json:"palette"`
+	Lives      int    `json:"lives"`
+	Snake      string `json:"snake"`
+	// Level is the level selected in the menu when the game starts
+	Level int               `json:"level"`
+	Keys  map[string]string `json:"keys"`
+}
+
+// NewConfigFile returns the settings in config as they are written to the
+// config file
+func NewConfigFile(config Config) ConfigFile {
+	return ConfigFile{
+		Difficulty: OptionDifficulty.value(config),
+		Sound:      config.soundEnabled,
+		Palette:    OptionPalette.value(config),
+		Lives:      config.startingLives,
+		Snake:      OptionSnakeStyle.value(config),
+		Level:      config.startLevel,
+		Keys:       config.keys.names(),
+	}
+}
+
+// apply returns config with the file's settings, or an error naming the first
+// one that isn't valid
+func (file ConfigFile) apply(config Config) (Config, error) {
+	var err error
+	if config, err = OptionDifficulty.set(config, file.Difficulty); err != nil {
+		return config, err
+	}
+	if config, err = OptionPalette.set(config, file.Palette); err != nil {
+		return config, err
+	}
+	if config, err = OptionLives.set(config, strconv.Itoa(file.Lives)); err != nil {
+		return config, err
+	}
+	if config, err = OptionSnakeStyle.set(config, file.Snake); err != nil {
+		return config, err
+	}
+	if config.keys, err = config.keys.withNames(file.Keys); err != nil {
+		return config, err
+	}
+	config.soundEnabled = file.Sound
+	config.startLevel = file.Level
+	return config, nil
+}
+
+// parseConfigFile reads a config file on top of config. settings missing from
+// content keep their value in config, and unknown ones are an error so typos
+// don't go unnoticed.
+func parseConfigFile(content []byte, config Config) (Config, error) {
+	file := NewConfigFile(config)
+	decoder := json.NewDecoder(bytes.NewReader(content))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&file); err != nil {
+		return config, fmt.Errorf("invalid config: %w", err)
+	}
+	result, err := file.apply(config)
+	if err != nil {
+		return config, fmt.Errorf("invalid config: %w", err)
+	}
+	return result, nil
+}
+
+// loadConfigFile reads the config file from the config directory on top of
+// config. when the file doesn't exist yet config is written to it and
+// returned. an invalid file returns config unchanged along with the error.
+func loadConfigFile(config Config) (Config, error) {
+	dir, err := configDir()
+	if err != nil {
+		return config, err
+	}
+
+	content, err := os.ReadFile(filepath.Join(dir, CONFIG_FILE))
+	if errors.Is(err, fs.ErrNotExist) {
+		return config, saveConfigFile(config)
+	}
+	if err != nil {
+		return config, err
+	}
+	return parseConfigFile(content, config)
+}
+
+// saveConfigFile writes the settings in config to the config directory,
+// creating the directory if needed
+func saveConfigFile(config Config) error {
+	dir, err := configDir()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+	content, err := json.MarshalIndent(NewConfigFile(config), "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(dir, CONFIG_FILE), append(content, '\n'), 0o644)
+}
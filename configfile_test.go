@@ -0,0 +1,91 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/hajimehoshi/ebiten/v2"
+)
+
+// useTempConfigDir points configDir at a new empty directory for the test,
+// whichever platform it runs on
+func useTempConfigDir(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", dir)
+	t.Setenv("HOME", dir)
+	t.Setenv("AppData", dir)
+}
+
+func TestParseConfigFileErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    string
+	}{
+		{"unknown field", `{"lifes": 3}`, `invalid config: json: unknown field "lifes"`},
+		{"too few lives", `{"lives": 0}`, `invalid config: expected lives from 1 to 9, got "0"`},
+		{"unknown action", `{"keys": {"jump": "Space"}}`, `invalid config: unknown action "jump"`},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			config := DefaultConfig()
+			got, err := parseConfigFile([]byte(test.content), config)
+			if err == nil || err.Error() != test.want {
+				t.Errorf("error = %v, want %q", err, test.want)
+			}
+			// the caller plays on with the config it passed in
+			if got.startingLives != config.startingLives || got.keys != config.keys {
+				t.Errorf("an invalid file changed the config to %d lives and keys %v", got.startingLives, got.keys.names())
+			}
+		})
+	}
+}
+
+func TestConfigFileRoundTrip(t *testing.T) {
+	config := DefaultConfig()
+	config.startingLives = 5
+	config.soundEnabled = false
+	config.keys.keys[ActionDig] = ebiten.KeyX
+	config.keys.keys[ActionUp] = ebiten.KeyI
+
+	useTempConfigDir(t)
+	if err := saveConfigFile(config); err != nil {
+		t.Fatal(err)
+	}
+	got, err := loadConfigFile(DefaultConfig())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.keys != config.keys {
+		t.Errorf("keys = %v, want %v", got.keys.names(), config.keys.names())
+	}
+	if got.startingLives != 5 || got.soundEnabled {
+		t.Errorf("lives %d and sound %t, want 5 and off", got.startingLives, got.soundEnabled)
+	}
+}
+
+func TestLoadConfigFileFirstRun(t *testing.T) {
+	useTempConfigDir(t)
+	config := DefaultConfig()
+	got, err := loadConfigFile(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.startingLives != config.startingLives {
+		t.Errorf("lives = %d on the first run, want the default %d", got.startingLives, config.startingLives)
+	}
+
+	dir, err := configDir()
+	if err != nil {
+		t.Fatal(err)
+	}
+	content, err := os.ReadFile(filepath.Join(dir, CONFIG_FILE))
+	if err != nil {
+		t.Fatalf("the first run didn't write the config file: %v", err)
+	}
+	if !strings.Contains(string(content), `"lives": 3`) {
+		t.Errorf("config file = %s, want the default lives written", content)
+	}
+}
@@ -0,0 +1,102 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"log"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DAILY_SCORE_FILE is the file in the config directory that holds the best
+// score in today's daily challenge, kept apart from the high score
+const DAILY_SCORE_FILE = "daily.txt"
+
+// dailySeed returns the seed for the daily challenge on the given day, which
+// is its date written as yyyymmdd so everyone playing that day gets the same
+// maze
+func dailySeed(day time.Time) int {
+	year, month, date := day.Date()
+	return year*10000 + int(month)*100 + date
+}
+
+// dailyDate returns the date a daily challenge seed was made from, as
+// yyyy-mm-dd
+func dailyDate(seed int) string {
+	return fmt.Sprintf("%04d-%02d-%02d", seed/10000, seed/100%100, seed%100)
+}
+
+// dailyConfig returns config set up for the daily challenge on the given day:
+// a generated maze seeded from the date, at normal difficulty so every player
+// faces the same ghosts
+func dailyConfig(config Config, day time.Time) Config {
+	config.seed = dailySeed(day)
+	config.generate = true
+	config.difficulty = DifficultyNormal
+	return config
+}
+
+// dailyShareText returns a line summing up a daily challenge, to be pasted
+// for others to compare against
+func dailyShareText(state *State) string {
+	result := "lost"
+	if state.status == StatusWon {
+		result = "won"
+	}
+	return fmt.Sprintf("%s daily %s: %d points, %s", strings.ToLower(TITLE), dailyDate(state.config.seed), state.score, result)
+}
+
+// loadDailyScore reads the best daily challenge score saved for the given
+// date. a missing file, or one saved on another day, means the day's
+// challenge hasn't been played yet, so the best is 0.
+func loadDailyScore(date string) (int, error) {
+	dir, err := configDir()
+	if err != nil {
+		return 0, err
+	}
+
+	content, err := os.ReadFile(filepath.Join(dir, DAILY_SCORE_FILE))
+	if errors.Is(err, fs.ErrNotExist) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	savedDate, score, _ := strings.Cut(strings.TrimSpace(string(content)), " ")
+	if savedDate != date {
+		return 0, nil
+	}
+	return strconv.Atoi(score)
+}
+
+// saveDailyScore writes the best score for the given date's daily challenge
+// to the config directory, replacing any earlier day's
+func saveDailyScore(date string, score int) error {
+	dir, err := configDir()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(dir, DAILY_SCORE_FILE), []byte(date+" "+strconv.Itoa(score)), 0o644)
+}
+
+// recordDailyScore logs the share text for a finished daily challenge, so it
+// can be copied from the terminal, and saves the score if it beats the day's
+// best. replays don't count.
+func (game *Game) recordDailyScore() {
+	log.Print(dailyShareText(&game.state))
+	if game.replay != nil || game.state.score <= game.dailyBest {
+		return
+	}
+	game.dailyBest = game.state.score
+	if err := saveDailyScore(dailyDate(game.state.config.seed), game.dailyBest); err != nil {
+		log.Printf("saving daily score: %v", err)
+	}
+}
@@ -0,0 +1,35 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+// dailyLevel returns the daily challenge's maze for the given day, written out
+// as a level file
+func dailyLevel(t *testing.T, day time.Time) string {
+	t.Helper()
+	config := dailyConfig(DefaultConfig(), day)
+	config.mode = ModeDaily
+	state, err := NewState(1, config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return state.level.String()
+}
+
+func TestDailyChallengeSeed(t *testing.T) {
+	morning := time.Date(2024, time.March, 9, 8, 0, 0, 0, time.UTC)
+	evening := time.Date(2024, time.March, 9, 23, 30, 0, 0, time.UTC)
+	nextDay := time.Date(2024, time.March, 10, 8, 0, 0, 0, time.UTC)
+
+	if dailySeed(morning) != 20240309 || dailyDate(dailySeed(morning)) != "2024-03-09" {
+		t.Errorf("seed %d for date %s, want 20240309 for 2024-03-09", dailySeed(morning), dailyDate(dailySeed(morning)))
+	}
+	if dailyLevel(t, morning) != dailyLevel(t, evening) {
+		t.Error("the same day gave different mazes")
+	}
+	if dailyLevel(t, morning) == dailyLevel(t, nextDay) {
+		t.Error("consecutive days gave the same maze")
+	}
+}
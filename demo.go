@@ -0,0 +1,86 @@
+package main
+
+import (
+	"math/rand"
+
+	"github.com/hajimehoshi/ebiten/v2"
+)
+
+// the demo snake circles the border of an empty level drawn around the title
+// on the start screen
+const (
+	DEMO_WIDTH         = 40
+	DEMO_HEIGHT        = 7
+	DEMO_CELL_SIZE     = 10
+	DEMO_SNAKE_LENGTH  = 12
+	DEMO_MOVE_INTERVAL = 4
+	DEMO_TOP           = 45 // pixels from the top of the screen
+)
+
+// NewDemoState creates a game on an empty level with a snake heading right
+// along the top edge. the level has no walls, food, or ghosts, and the exit
+// sits in the middle where the snake never goes, so steering it around the
+// border keeps it moving forever.
+func NewDemoState(config Config) State {
+	level := Level{
+		width:      DEMO_WIDTH,
+		height:     DEMO_HEIGHT,
+		walls:      make(Slice[Slice[bool]], DEMO_HEIGHT),
+		foods:      Slice[Food]{},
+		foodValues: map[Vec2]int{},
+		portals:    map[Vec2]Vec2{},
+		arrows:     map[Vec2]Vec2{},
+		ghosts:     Slice[Ghost]{},
+		exit:       Vec2{x: DEMO_WIDTH / 2, y: DEMO_HEIGHT / 2},
+	}
+	for y := range level.walls {
+		level.walls[y] = make(Slice[bool], DEMO_WIDTH)
+	}
+
+	snake := NewSnake(Vec2{x: DEMO_SNAKE_LENGTH - 1, y: 0}, DEMO_MOVE_INTERVAL)
+	for x := DEMO_SNAKE_LENGTH - 2; x >= 0; x-- {
+		snake.body = append(snake.body, Vec2{x: x, y: 0})
+	}
+	snake.direction = Vec2{x: 1, y: 0}
+	snake.prevDirection = snake.direction
+
+	return State{
+		status: StatusPlaying,
+		level:  level,
+		snakes: NewSlice(snake),
+		config: config,
+		rng:    rand.New(rand.NewSource(int64(config.seed))),
+		layout: Layout{width: DEMO_WIDTH * DEMO_CELL_SIZE, height: DEMO_HEIGHT * DEMO_CELL_SIZE, cellSize: DEMO_CELL_SIZE},
+	}
+}
+
+// updateDemo turns the demo snake clockwise whenever its next step would
+// leave the level, then moves it with the same logic as a real game
+func (game *Game) updateDemo() {
+	demo := &game.demo
+	demo.config.palette = game.config.palette
+	demo.config.snakeStyle = game.config.snakeStyle
+
+	snake := &demo.snakes[0]
+	head := snake.getHead()
+	next := head.add(snake.direction)
+	if next.x < 0 || next.x >= demo.level.width || next.y < 0 || next.y >= demo.level.height {
+		snake.direction = Vec2{x: -snake.direction.y, y: snake.direction.x}
+	}
+	snake.move(demo)
+	// the demo plays silently behind the menu
+	demo.pendingSounds = nil
+}
+
+// drawStartScreen draws the demo snake faintly behind the menu
+func (game *Game) drawStartScreen(screen *ebiten.Image) {
+	game.demoImage.Clear()
+	drawSnake(game.demoImage, &game.demo, &game.sprites)
+
+	op := &ebiten.DrawImageOptions{}
+	op.GeoM.Translate(float64(game.layout.width-game.demo.layout.width)/2, DEMO_TOP)
+	op.ColorScale.ScaleAlpha(0.6)
+	screen.DrawImage(game.demoImage, op)
+
+	game.drawMenu(screen)
+}
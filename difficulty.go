@@ -0,0 +1,137 @@
+package main
+
+// Difficulty scales the snake's speed, lives, power-ups, and ghosts
+type Difficulty int
+
+const (
+	DifficultyEasy Difficulty = iota
+	DifficultyNormal
+	DifficultyHard
+)
+
+func (difficulty Difficulty) String() string {
+	switch difficulty {
+	case DifficultyEasy:
+		return "easy"
+	case DifficultyNormal:
+		return "normal"
+	case DifficultyHard:
+		return "hard"
+	}
+	return "unknown"
+}
+
+// next returns the difficulty after this one, wrapping back to the first
+func (difficulty Difficulty) next() Difficulty {
+	return (difficulty + 1) % (DifficultyHard + 1)
+}
+
+// apply returns the config with its speed, lives, and power-up time scaled for
+// the difficulty. it's applied once when NewState starts a game, so the
+// config stored on State already holds the scaled values.
+func (difficulty Difficulty) apply(config Config) Config {
+	switch difficulty {
+	case DifficultyEasy:
+		config.moveInterval += 3
+		config.minMoveInterval += 2
+		config.startingLives += 2
+		config.powerUpTime = config.powerUpTime * 3 / 2
+	case DifficultyHard:
+		config.moveInterval -= 3
+		config.minMoveInterval -= 1
+		config.startingLives -= 1
+		config.powerUpTime /= 2
+		// a config already at the fastest speed or on one life can't go any
+		// lower: the snake only moves on intervals of at least 1 frame, and a
+		// game with no lives would be over before it started
+		if config.moveInterval < 1 {
+			config.moveInterval = 1
+		}
+		if config.minMoveInterval < 1 {
+			config.minMoveInterval = 1
+		}
+		if config.startingLives < 1 {
+			config.startingLives = 1
+		}
+		// speeding up can't start the snake past its top speed
+		if config.moveInterval < config.minMoveInterval {
+			config.moveInterval = config.minMoveInterval
+		}
+	}
+	return config
+}
+
+// ghostCount returns how many ghosts a level written with the given number
+// should have at this difficulty. easy halves them, and hard adds half again
+// with at least one ghost even on levels written without any.
+func (difficulty Difficulty) ghostCount(count int) int {
+	switch difficulty {
+	case DifficultyEasy:
+		return count / 2
+	case DifficultyHard:
+		return count + count/2 + 1
+	}
+	return count
+}
+
+// ghostCount returns how many ghosts a level written with the given number
+// should have in a game played with config. zen games have none at all.
+func (config Config) ghostCount(count int) int {
+	if config.mode == ModeZen {
+		return 0
+	}
+	return config.difficulty.ghostCount(count)
+}
+
+// setGhostCount removes ghosts from the end of the level's list, or adds new
+// ones on the empty cells furthest from the entrance, measured the short way
+// round where the level wraps, until it has count. cells are picked in grid
+// order so the same level always gets the same ghosts.
+func (level *Level) setGhostCount(count int) {
+	if count <= len(level.ghosts) {
+		level.ghosts = level.ghosts[:count]
+		return
+	}
+
+	occupied := map[Vec2]bool{level.entrance: true, level.exit: true, level.rivalEntrance(): true}
+	for _, food := range level.foods {
+		occupied[food.position] = true
+	}
+	for _, pickup := range level.digPickups {
+		occupied[pickup] = true
+	}
+	for _, pickup := range level.slowPickups {
+		occupied[pickup] = true
+	}
+	for _, gate := range level.gates {
+		occupied[gate.position] = true
+	}
+	for position := range level.portals {
+		occupied[position] = true
+	}
+	for position := range level.arrows {
+		occupied[position] = true
+	}
+	for _, ghost := range level.ghosts {
+		occupied[ghost.position] = true
+	}
+
+	reached := level.reachableFrom(level.entrance)
+	for len(level.ghosts) < count {
+		best, bestDistance := Vec2{}, -1
+		for y := 0; y < level.height; y++ {
+			for x := 0; x < level.width; x++ {
+				position := Vec2{x: x, y: y}
+				distance := level.wrappedDistance(position, level.entrance)
+				if reached[position] && !occupied[position] && distance > bestDistance {
+					best, bestDistance = position, distance
+				}
+			}
+		}
+		if bestDistance < 0 {
+			return
+		}
+		occupied[best] = true
+		level.ghosts = append(level.ghosts, NewGhost(best))
+	}
+}
@@ -0,0 +1,90 @@
+package main
+
+import "testing"
+
+func TestHardDifficultyClamps(t *testing.T) {
+	tests := []struct {
+		name            string
+		moveInterval    int
+		minMoveInterval int
+		startingLives   int
+		want            [3]int
+	}{
+		{"defaults", 8, 4, 3, [3]int{5, 3, 2}},
+		{"one life", 8, 4, 1, [3]int{5, 3, 1}},
+		{"fastest speed", 2, 1, 3, [3]int{1, 1, 2}},
+		{"close to the top speed", 5, 4, 3, [3]int{3, 3, 2}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			config := DefaultConfig()
+			config.moveInterval = test.moveInterval
+			config.minMoveInterval = test.minMoveInterval
+			config.startingLives = test.startingLives
+			config = DifficultyHard.apply(config)
+			got := [3]int{config.moveInterval, config.minMoveInterval, config.startingLives}
+			if got != test.want {
+				t.Errorf("move interval, min move interval, lives = %v, want %v", got, test.want)
+			}
+		})
+	}
+}
+
+func TestSetGhostCountFarthestThroughTheWrap(t *testing.T) {
+	tests := []struct {
+		name  string
+		level string
+		want  Vec2
+	}{
+		// the cell at the far end is right next to the entrance through the
+		// wrap, so the ghost goes in the middle instead
+		{"wrapping", "#########\n.S.F...E.\n#########\n", Vec2{x: 5, y: 1}},
+		{"solid edges", ";wrap=false\n#########\n.S.F...E.\n#########\n", Vec2{x: 8, y: 1}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			level, err := parseLevel(1, test.level)
+			if err != nil {
+				t.Fatal(err)
+			}
+			level.ghosts = nil
+			level.setGhostCount(1)
+			if len(level.ghosts) != 1 || level.ghosts[0].position != test.want {
+				t.Errorf("ghosts = %v, want one at %v", level.ghosts, test.want)
+			}
+		})
+	}
+}
+
+func TestNewStateDifficulty(t *testing.T) {
+	level := "#########\n#S.G.G..#\n#.......#\n#F.....E#\n#########\n"
+	tests := []struct {
+		difficulty Difficulty
+		ghosts     int
+	}{
+		{DifficultyEasy, 1},
+		{DifficultyNormal, 2},
+		{DifficultyHard, 4},
+	}
+	for _, test := range tests {
+		t.Run(test.difficulty.String(), func(t *testing.T) {
+			config := DefaultConfig()
+			config.levels = testLevels(level)
+			config.difficulty = test.difficulty
+			state, err := NewState(1, config)
+			if err != nil {
+				t.Fatal(err)
+			}
+			want := test.difficulty.apply(DefaultConfig())
+			if state.config.moveInterval != want.moveInterval || state.snakes[0].moveInterval != want.moveInterval {
+				t.Errorf("config and snake move intervals %d and %d, want %d", state.config.moveInterval, state.snakes[0].moveInterval, want.moveInterval)
+			}
+			if state.lives != want.startingLives {
+				t.Errorf("lives = %d, want %d", state.lives, want.startingLives)
+			}
+			if len(state.level.ghosts) != test.ghosts {
+				t.Errorf("ghosts = %d, want %d", len(state.level.ghosts), test.ghosts)
+			}
+		})
+	}
+}
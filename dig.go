@@ -0,0 +1,51 @@
+package main
+
+import (
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/vector"
+)
+
+// DIG_CHARGES_PER_PICKUP is the number of dig charges a dig tile gives
+const DIG_CHARGES_PER_PICKUP = 3
+
+// DIG_INPUT is the bit set in an input mask, past the direction bits, while
+// the dig key is held
+const DIG_INPUT = 1 << len(directions)
+
+// canDig reports whether the snake would dig through a wall in its way rather
+// than crash into it: the dig key must be held and a charge left to spend
+func (snake *Snake) canDig(state *State) bool {
+	return snake.digging && state.digCharges > 0
+}
+
+// dig removes the wall at position and spends a dig charge on it
+func dig(state *State, position Vec2) {
+	state.level.walls[position.y][position.x] = false
+	state.digCharges -= 1
+	state.invalidateWalls()
+	state.playSound(SoundEat)
+}
+
+// eatDigPickup collects the dig tile under the snake's head, if there is one
+func (snake *Snake) eatDigPickup(state *State) {
+	for i, pickup := range state.level.digPickups {
+		if snake.getHead() == pickup {
+			state.level.digPickups = state.level.digPickups.removeAt(i)
+			state.digCharges += DIG_CHARGES_PER_PICKUP
+			state.playSound(SoundEat)
+			return
+		}
+	}
+}
+
+// drawDigPickups draws each dig tile as a small block of wall in the middle
+// of its cell
+func drawDigPickups(screen *ebiten.Image, state *State) {
+	size := float32(state.layout.cellSize)
+	for _, pickup := range state.level.digPickups {
+		if isVisible(state, pickup) {
+			offset := viewportOffset(state, pickup)
+			vector.DrawFilledRect(screen, (float32(offset.x)+0.25)*size, (float32(offset.y)+0.25)*size, size/2, size/2, state.config.palette.wall, true)
+		}
+	}
+}
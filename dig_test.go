@@ -0,0 +1,33 @@
+package main
+
+import "testing"
+
+func TestDigCharge(t *testing.T) {
+	state := newTestState(t, "########\n#S.#.#E#\n#F.....#\n########\n")
+	state.status = StatusPlaying
+	state.digCharges = 1
+	lives := state.lives
+
+	updateUntilMoved(t, &state, RIGHT_INPUT|DIG_INPUT)
+	updateUntilMoved(t, &state, RIGHT_INPUT|DIG_INPUT)
+	if head := state.snakes[0].getHead(); head != (Vec2{x: 3, y: 1}) {
+		t.Fatalf("head = %v, want it through the wall at {3 1}", head)
+	}
+	if state.level.walls[1][3] {
+		t.Error("the dug wall is still there")
+	}
+	if state.digCharges != 0 {
+		t.Errorf("dig charges = %d, want the one charge used up", state.digCharges)
+	}
+
+	updateUntilMoved(t, &state, RIGHT_INPUT|DIG_INPUT)
+	for i := 0; i < 100 && state.lives == lives; i++ {
+		state.update(NewSlice(RIGHT_INPUT | DIG_INPUT))
+	}
+	if state.lives != lives-1 {
+		t.Errorf("lives = %d, want one lost to the next wall with no charge left", state.lives)
+	}
+	if !state.level.walls[1][5] {
+		t.Error("the wall at {5 1} was dug without a charge")
+	}
+}
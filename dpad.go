@@ -0,0 +1,93 @@
+package main
+
+import (
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/vector"
+)
+
+const (
+	DPAD_RADIUS = 60 // half the width of the virtual d-pad, in pixels
+	DPAD_MARGIN = 20 // gap between the d-pad and the corner of the screen
+	// DPAD_DEAD_ZONE is how far from the middle of the d-pad a touch has to be
+	// before it counts as pressing one of its arms
+	DPAD_DEAD_ZONE = 8
+)
+
+// DPad is an on-screen d-pad in the bottom left corner for touch screens. it
+// stays hidden until the first touch, so it never covers the level on
+// platforms without one.
+type DPad struct {
+	shown bool
+	// pressed is the direction of the arm being touched, or the zero vector
+	pressed Vec2
+}
+
+// dpadCenter returns the middle of the d-pad on a screen of the given size
+func dpadCenter(width int, height int) (x int, y int) {
+	return DPAD_MARGIN + DPAD_RADIUS, height - DPAD_MARGIN - DPAD_RADIUS
+}
+
+// dpadDirection returns the direction of the d-pad quadrant a touch at the
+// given offset from its middle is in, or the zero vector if it's outside the
+// d-pad, inside DPAD_DEAD_ZONE, or exactly diagonal
+func dpadDirection(dx int, dy int) Vec2 {
+	absX, absY := abs(dx), abs(dy)
+	if absX > DPAD_RADIUS || absY > DPAD_RADIUS {
+		return Vec2{}
+	}
+	if absX < DPAD_DEAD_ZONE && absY < DPAD_DEAD_ZONE {
+		return Vec2{}
+	}
+	return dominantDirection(dx, dy)
+}
+
+// update checks every touch against the d-pad and returns an input mask with
+// the bit set for the arm being held, or 0 if none is. like a swipe, the mask
+// goes through handleInput as a held key would.
+func (dpad *DPad) update(width int, height int) int {
+	touches := ebiten.AppendTouchIDs(nil)
+	if len(touches) > 0 {
+		dpad.shown = true
+	}
+
+	centerX, centerY := dpadCenter(width, height)
+	dpad.pressed = Vec2{}
+	for _, touch := range touches {
+		x, y := ebiten.TouchPosition(touch)
+		if direction := dpadDirection(x-centerX, y-centerY); direction != (Vec2{}) {
+			dpad.pressed = direction
+			break
+		}
+	}
+
+	for i, d := range directions {
+		if d == dpad.pressed && d != (Vec2{}) {
+			return 1 << i
+		}
+	}
+	return 0
+}
+
+// draw draws the d-pad's four arms, see-through so the level shows behind
+// them, with the arm being held drawn brighter
+func (dpad *DPad) draw(screen *ebiten.Image, palette Palette) {
+	if !dpad.shown {
+		return
+	}
+	centerX, centerY := dpadCenter(screen.Bounds().Dx(), screen.Bounds().Dy())
+	arm := float32(DPAD_RADIUS) * 2 / 3
+	for _, d := range directions {
+		// colors are premultiplied, so making one see-through scales every
+		// channel
+		alpha := 0.3
+		if d == dpad.pressed {
+			alpha = 0.6
+		}
+		c := dimColor(palette.menuItem, alpha)
+		c.A = uint8(float64(c.A) * alpha)
+
+		left := float32(centerX) + float32(d.x)*arm - arm/2
+		top := float32(centerY) + float32(d.y)*arm - arm/2
+		vector.DrawFilledRect(screen, left, top, arm, arm, c, true)
+	}
+}
@@ -0,0 +1,43 @@
+package main
+
+import "testing"
+
+func TestDPadDirection(t *testing.T) {
+	tests := []struct {
+		name string
+		dx   int
+		dy   int
+		want Vec2
+	}{
+		{"middle", 0, 0, Vec2{}},
+		{"inside the dead zone", DPAD_DEAD_ZONE - 1, -(DPAD_DEAD_ZONE - 1), Vec2{}},
+		{"edge of the dead zone", DPAD_DEAD_ZONE, 0, Vec2{x: 1, y: 0}},
+		{"up arm", 5, -40, Vec2{x: 0, y: -1}},
+		{"down arm", -5, 40, Vec2{x: 0, y: 1}},
+		{"left arm", -40, 5, Vec2{x: -1, y: 0}},
+		{"right arm", 40, -5, Vec2{x: 1, y: 0}},
+		{"tip of the up arm", 0, -DPAD_RADIUS, Vec2{x: 0, y: -1}},
+		{"just past the up arm", 0, -(DPAD_RADIUS + 1), Vec2{}},
+		{"just past the right arm", DPAD_RADIUS + 1, 0, Vec2{}},
+		{"exact diagonal", 30, 30, Vec2{}},
+		{"far corner", DPAD_RADIUS, -DPAD_RADIUS + 1, Vec2{x: 1, y: 0}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := dpadDirection(test.dx, test.dy); got != test.want {
+				t.Errorf("dpadDirection(%d, %d) = %v, want %v", test.dx, test.dy, got, test.want)
+			}
+		})
+	}
+}
+
+func TestDPadCenter(t *testing.T) {
+	x, y := dpadCenter(640, 480)
+	if x != DPAD_MARGIN+DPAD_RADIUS || y != 480-DPAD_MARGIN-DPAD_RADIUS {
+		t.Errorf("dpadCenter(640, 480) = %d, %d, want the bottom left corner inset by the margin", x, y)
+	}
+	// a touch on the screen's bottom left corner is past the d-pad
+	if direction := dpadDirection(0-x, 480-y); direction != (Vec2{}) {
+		t.Errorf("touch in the corner pressed %v, want nothing", direction)
+	}
+}
@@ -0,0 +1,307 @@
+package main
+
+import (
+	"fmt"
+	"image/color"
+	"os"
+	"path/filepath"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/inpututil"
+	"github.com/hajimehoshi/ebiten/v2/text/v2"
+	"github.com/hajimehoshi/ebiten/v2/vector"
+)
+
+// LEVELS_DIR is the folder in the config directory that edited levels are
+// saved to
+const LEVELS_DIR = "levels"
+
+// Tool is what the mouse paints onto the level in the editor
+type Tool int
+
+const (
+	ToolWall Tool = iota
+	ToolFood
+	ToolEntrance
+	ToolExit
+	ToolGhost
+	ToolPowerPellet
+	ToolDigPickup
+	ToolGate
+	ToolSlowPickup
+)
+
+// tools lists every tool in the order of the number keys that select them
+var tools = [9]Tool{ToolWall, ToolFood, ToolEntrance, ToolExit, ToolGhost, ToolPowerPellet, ToolDigPickup, ToolGate, ToolSlowPickup}
+
+func (tool Tool) String() string {
+	switch tool {
+	case ToolWall:
+		return "wall"
+	case ToolFood:
+		return "food"
+	case ToolEntrance:
+		return "start"
+	case ToolExit:
+		return "exit"
+	case ToolGhost:
+		return "ghost"
+	case ToolPowerPellet:
+		return "pellet"
+	case ToolDigPickup:
+		return "dig"
+	case ToolGate:
+		return "gate"
+	case ToolSlowPickup:
+		return "slow"
+	}
+	return "unknown"
+}
+
+// color returns the color a tool's swatch is drawn with. the exit uses the
+// menu selection color since it is drawn the same as the background.
+func (tool Tool) color(palette Palette) color.RGBA {
+	switch tool {
+	case ToolWall:
+		return palette.wall
+	case ToolFood:
+		return palette.food
+	case ToolEntrance:
+		return palette.snake
+	case ToolExit:
+		return palette.menuSelection
+	case ToolGhost:
+		return palette.ghost
+	case ToolPowerPellet, ToolSlowPickup:
+		return palette.powerUp
+	case ToolDigPickup, ToolGate:
+		return palette.wall
+	}
+	return palette.background
+}
+
+// paint places the given tool at the position, replacing whatever was there.
+// the entrance and exit are moved rather than added.
+func (level *Level) paint(position Vec2, tool Tool) {
+	level.erase(position)
+	switch tool {
+	case ToolWall:
+		level.walls[position.y][position.x] = true
+	case ToolFood:
+		level.foods = append(level.foods, Food{position: position, kind: FoodNormal})
+	case ToolEntrance:
+		level.entrance = position
+	case ToolExit:
+		level.exit = position
+	case ToolGhost:
+		level.ghosts = append(level.ghosts, NewGhost(position))
+	case ToolPowerPellet:
+		level.foods = append(level.foods, Food{position: position, kind: FoodPower})
+	case ToolDigPickup:
+		level.digPickups = append(level.digPickups, position)
+	case ToolSlowPickup:
+		level.slowPickups = append(level.slowPickups, position)
+	case ToolGate:
+		// the level file holds one period for all of its gates
+		period := GATE_PERIOD
+		if len(level.gates) > 0 {
+			period = level.gates[0].period
+		}
+		level.gates = append(level.gates, NewGate(position, period, false))
+	}
+}
+
+// erase clears any wall, food, power pellet, dig or slow-motion tile, gate,
+// ghost, arrow, or portal pair at the position. the entrance and exit are
+// left alone, since a level always needs them.
+func (level *Level) erase(position Vec2) {
+	level.walls[position.y][position.x] = false
+	for i := len(level.foods) - 1; i >= 0; i-- {
+		if level.foods[i].position == position {
+			level.foods = level.foods.removeAt(i)
+		}
+	}
+	delete(level.foodValues, position)
+	for i := len(level.digPickups) - 1; i >= 0; i-- {
+		if level.digPickups[i] == position {
+			level.digPickups = level.digPickups.removeAt(i)
+		}
+	}
+	for i := len(level.slowPickups) - 1; i >= 0; i-- {
+		if level.slowPickups[i] == position {
+			level.slowPickups = level.slowPickups.removeAt(i)
+		}
+	}
+	for i := len(level.gates) - 1; i >= 0; i-- {
+		if level.gates[i].position == position {
+			level.gates = level.gates.removeAt(i)
+		}
+	}
+	delete(level.arrows, position)
+	// a portal can't be left without its partner
+	if partner, ok := level.portals[position]; ok {
+		delete(level.portals, position)
+		delete(level.portals, partner)
+	}
+	for i := len(level.ghosts) - 1; i >= 0; i-- {
+		if level.ghosts[i].position == position {
+			level.ghosts = level.ghosts.removeAt(i)
+		}
+	}
+}
+
+// saveLevel writes the level to the levels folder in the config directory,
+// creating the folder if needed, and returns the path it was written to
+func saveLevel(level *Level) (string, error) {
+	dir, err := configDir()
+	if err != nil {
+		return "", err
+	}
+	dir = filepath.Join(dir, LEVELS_DIR)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return "", err
+	}
+
+	path := filepath.Join(dir, fmt.Sprintf("level-%d.txt", level.id))
+	if err := os.WriteFile(path, []byte(level.String()), 0o644); err != nil {
+		return "", err
+	}
+	return path, nil
+}
+
+// cellAt returns the level position drawn under the given screen pixel, or
+// false if no level cell is drawn there
+func cellAt(state *State, x int, y int) (Vec2, bool) {
+	size := state.layout.cellSize
+	if x < 0 || y < 0 || x/size >= state.layout.viewportWidth() || y/size >= state.layout.viewportHeight() {
+		return Vec2{}, false
+	}
+
+	margin := viewportMargin(state)
+	position := Vec2{x: x/size - margin.x + state.viewportX, y: y/size - margin.y + state.viewportY}
+	if state.level.width >= state.layout.viewportWidth() {
+		position.x = mod(position.x, state.level.width)
+	}
+	if state.level.height >= state.layout.viewportHeight() {
+		position.y = mod(position.y, state.level.height)
+	}
+	if position.x < 0 || position.x >= state.level.width || position.y < 0 || position.y >= state.level.height {
+		return Vec2{}, false
+	}
+	return position, true
+}
+
+// startEditor opens the level with the given id in the editor
+func (game *Game) startEditor(levelID int) error {
+	// the level is edited as written, without ghosts added or removed for the
+	// difficulty or mode
+	config := game.config
+	config.difficulty = DifficultyNormal
+	config.mode = ModeCampaign
+	newState, err := NewState(levelID, config)
+	if err != nil {
+		return err
+	}
+	newState.layout = game.layout
+	game.state = newState
+	game.state.status = StatusEditing
+	game.editorTool = ToolWall
+	game.editorMessage = ""
+	return nil
+}
+
+// updateEditorState paints the selected tool with the left mouse button and
+// erases with the right. the number keys pick a tool, the arrows scroll, S
+// saves the level once it passes validation, and Esc returns to the menu.
+func (game *Game) updateEditorState() {
+	state := &game.state
+
+	if inpututil.IsKeyJustPressed(ebiten.KeyEscape) {
+		state.status = StatusStarted
+		return
+	}
+
+	for i, tool := range tools {
+		if inpututil.IsKeyJustPressed(ebiten.Key1 + ebiten.Key(i)) {
+			game.editorTool = tool
+		}
+	}
+
+	scroll := func(viewport int, delta int, viewportSize int, levelSize int) int {
+		if levelSize >= viewportSize {
+			return mod(viewport+delta, levelSize)
+		}
+		return 0
+	}
+	for i, key := range game.config.keys.directionKeys() {
+		if inpututil.IsKeyJustPressed(key) {
+			state.viewportX = scroll(state.viewportX, directions[i].x, state.layout.viewportWidth(), state.level.width)
+			state.viewportY = scroll(state.viewportY, directions[i].y, state.layout.viewportHeight(), state.level.height)
+		}
+	}
+
+	cursorX, cursorY := ebiten.CursorPosition()
+	if position, ok := cellAt(state, cursorX, cursorY); ok {
+		if ebiten.IsMouseButtonPressed(ebiten.MouseButtonLeft) {
+			state.level.paint(position, game.editorTool)
+			state.invalidateWalls()
+			game.editorMessage = ""
+		} else if ebiten.IsMouseButtonPressed(ebiten.MouseButtonRight) {
+			state.level.erase(position)
+			state.invalidateWalls()
+			game.editorMessage = ""
+		}
+	}
+
+	if inpututil.IsKeyJustPressed(ebiten.KeyS) {
+		// parsing the saved text catches everything a level file would be
+		// rejected for, including levels that can't be solved
+		if _, err := parseLevel(state.level.id, state.level.String()); err != nil {
+			game.editorMessage = "not saved: " + err.Error()
+			return
+		}
+		path, err := saveLevel(&state.level)
+		if err != nil {
+			game.editorMessage = "not saved: " + err.Error()
+			return
+		}
+		game.editorMessage = "saved to " + path
+	}
+}
+
+// drawEditor draws the level being edited with its entrance and exit marked,
+// the cell under the mouse, and a palette of tools along the bottom
+func (game *Game) drawEditor(screen *ebiten.Image) {
+	state := &game.state
+	palette := state.config.palette
+
+	drawLevel(screen, state, &game.sprites)
+	drawGhosts(screen, state, &game.sprites)
+	if isVisible(state, state.level.entrance) {
+		drawCell(screen, state, state.level.entrance, palette.snake)
+	}
+	if isVisible(state, state.level.exit) {
+		drawCellOutline(screen, state, state.level.exit, ToolExit.color(palette))
+	}
+	cursorX, cursorY := ebiten.CursorPosition()
+	if position, ok := cellAt(state, cursorX, cursorY); ok {
+		drawCellOutline(screen, state, position, palette.menuItem)
+	}
+
+	// draw a swatch for each tool with the selected one outlined
+	size := float32(state.layout.cellSize)
+	top := float32(game.layout.height) - size - 40
+	for i, tool := range tools {
+		left := 10 + float32(i)*(size+10)
+		vector.DrawFilledRect(screen, left, top, size, size, tool.color(palette), true)
+		if tool == game.editorTool {
+			vector.StrokeRect(screen, left-3, top-3, size+6, size+6, 2, palette.menuSelection, true)
+		}
+	}
+
+	op := &text.DrawOptions{}
+	op.GeoM.Translate(10, float64(game.layout.height)-30)
+	text.Draw(screen, "tool: "+game.editorTool.String()+"  1-9 pick, S save, ESC menu", &game.font.tiny, op)
+	op.GeoM.Translate(0, 15)
+	text.Draw(screen, game.editorMessage, &game.font.tiny, op)
+}
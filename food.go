@@ -0,0 +1,62 @@
+package main
+
+// FoodKind is what happens when a food is eaten
+type FoodKind int
+
+const (
+	// FoodNormal scores its value and makes the snake grow
+	FoodNormal FoodKind = iota
+	// FoodPower starts a power-up, so ghosts can be eaten
+	FoodPower
+	// FoodShrink takes SHRINK_SEGMENTS off the end of the snake, and costs a
+	// life if the snake is too short to lose them
+	FoodShrink
+	// FoodBonus scores BONUS_POINTS and makes the snake grow
+	FoodBonus
+)
+
+// SHRINK_SEGMENTS is how many segments shrink food takes off the snake
+const SHRINK_SEGMENTS = 2
+
+// foodKinds maps the level file characters for each food kind other than
+// plain food, which is written as 'F' or as its value from '1' to '9'
+var foodKinds = map[rune]FoodKind{
+	'P': FoodPower,
+	'-': FoodShrink,
+	'$': FoodBonus,
+}
+
+// Food is something on the level the snake eats when its head reaches it
+type Food struct {
+	position Vec2
+	kind     FoodKind
+}
+
+// scores reports whether eating the food is worth points. levels need at
+// least one food that scores, and endless games respawn food once there is
+// none left that does.
+func (kind FoodKind) scores() bool {
+	return kind == FoodNormal || kind == FoodBonus
+}
+
+// foodAt returns the index in level.foods of the food at position, or -1 if
+// there is none
+func (level *Level) foodAt(position Vec2) int {
+	for i, food := range level.foods {
+		if food.position == position {
+			return i
+		}
+	}
+	return -1
+}
+
+// scoringFoods returns the positions of the foods that are worth points
+func (level *Level) scoringFoods() Slice[Vec2] {
+	positions := NewSlice[Vec2]()
+	for _, food := range level.foods {
+		if food.kind.scores() {
+			positions = append(positions, food.position)
+		}
+	}
+	return positions
+}
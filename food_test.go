@@ -0,0 +1,73 @@
+package main
+
+import "testing"
+
+func TestFoodKinds(t *testing.T) {
+	tests := []struct {
+		name       string
+		char       string
+		wantScore  int
+		wantLength int
+		wantPower  bool
+	}{
+		{"plain", "F", 1, 6, false},
+		{"valued", "7", 7, 6, false},
+		{"power", "P", 0, 5, true},
+		{"shrink", "-", 0, 5 - SHRINK_SEGMENTS, false},
+		{"bonus", "$", BONUS_POINTS, 6, false},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			state := newTestState(t, "##########\n#S."+test.char+"....E#\n#F.......#\n##########\n")
+			state.snakes[0].body = NewSlice(Vec2{x: 2, y: 1}, Vec2{x: 2, y: 2}, Vec2{x: 3, y: 2}, Vec2{x: 4, y: 2}, Vec2{x: 5, y: 2})
+			stepUntilMoved(t, &state, Vec2{x: 1, y: 0})
+
+			if state.level.foodAt(Vec2{x: 3, y: 1}) != -1 {
+				t.Error("the food is still there")
+			}
+			if state.score != test.wantScore {
+				t.Errorf("score = %d, want %d", state.score, test.wantScore)
+			}
+			if length := len(state.snakes[0].body); length != test.wantLength {
+				t.Errorf("length = %d, want %d", length, test.wantLength)
+			}
+			if power := state.powerUpTimer > 0; power != test.wantPower {
+				t.Errorf("power-up active %v, want %v", power, test.wantPower)
+			}
+		})
+	}
+}
+
+func TestShrinkTooShort(t *testing.T) {
+	for length := 1; length <= SHRINK_SEGMENTS; length++ {
+		state := newTestState(t, "##########\n#S.-....E#\n#F.......#\n##########\n")
+		body := NewSlice(Vec2{x: 2, y: 1})
+		for i := 1; i < length; i++ {
+			body = append(body, Vec2{x: 2 - i, y: 1})
+		}
+		state.snakes[0].body = body
+		state.lives = 1
+		state.Step(Vec2{x: 1, y: 0})
+		for i := 0; i < 1000 && (state.status == StatusPlaying || state.status == StatusDying); i++ {
+			state.Step(Vec2{})
+		}
+		if state.status != StatusLost {
+			t.Errorf("length %d: status = %v after eating shrink food on the last life, want lost", length, state.status)
+		}
+	}
+}
+
+func TestShrinkTooShortCostsALife(t *testing.T) {
+	state := newTestState(t, "##########\n#S-.....E#\n#F.......#\n##########\n")
+	lives := state.lives
+	state.Step(Vec2{x: 1, y: 0})
+	for i := 0; i < 100 && state.lives == lives; i++ {
+		state.Step(Vec2{})
+	}
+	if state.lives != lives-1 {
+		t.Errorf("lives = %d, want one lost to shrinking a one segment snake", state.lives)
+	}
+	if head := state.snakes[0].getHead(); head != state.level.entrance || len(state.snakes[0].body) != 1 {
+		t.Errorf("snake = %v, want it respawned at the entrance %v", state.snakes[0].body, state.level.entrance)
+	}
+}
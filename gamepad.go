@@ -0,0 +1,86 @@
+package main
+
+import (
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/inpututil"
+)
+
+const (
+	GAMEPAD_DEADZONE = 0.5 // analog stick values below this are ignored
+
+	GAMEPAD_START_BUTTON   = ebiten.StandardGamepadButtonCenterRight // start
+	GAMEPAD_RESTART_BUTTON = ebiten.StandardGamepadButtonRightBottom // A
+)
+
+// gamepadIDs returns the currently connected gamepads. it's queried every time
+// rather than cached, so pads can be plugged in or removed at any point and the
+// keyboard keeps working when there are none.
+func gamepadIDs() []ebiten.GamepadID {
+	return ebiten.AppendGamepadIDs(nil)
+}
+
+// dpadButton returns the standard layout D-pad button for the given direction
+func dpadButton(direction Vec2) ebiten.StandardGamepadButton {
+	switch direction {
+	case Vec2{x: -1, y: 0}:
+		return ebiten.StandardGamepadButtonLeftLeft
+	case Vec2{x: 1, y: 0}:
+		return ebiten.StandardGamepadButtonLeftRight
+	case Vec2{x: 0, y: -1}:
+		return ebiten.StandardGamepadButtonLeftTop
+	default:
+		return ebiten.StandardGamepadButtonLeftBottom
+	}
+}
+
+// isGamepadDirectionPressed reports whether any connected gamepad is pushing
+// the D-pad or left stick in the given direction. gamepads without a standard
+// layout fall back to their first two axes.
+func isGamepadDirectionPressed(direction Vec2) bool {
+	for _, id := range gamepadIDs() {
+		var x, y float64
+		if ebiten.IsStandardGamepadLayoutAvailable(id) {
+			if ebiten.IsStandardGamepadButtonPressed(id, dpadButton(direction)) {
+				return true
+			}
+			x = ebiten.StandardGamepadAxisValue(id, ebiten.StandardGamepadAxisLeftStickHorizontal)
+			y = ebiten.StandardGamepadAxisValue(id, ebiten.StandardGamepadAxisLeftStickVertical)
+		} else {
+			x = ebiten.GamepadAxisValue(id, 0)
+			y = ebiten.GamepadAxisValue(id, 1)
+		}
+
+		if x*float64(direction.x) > GAMEPAD_DEADZONE || y*float64(direction.y) > GAMEPAD_DEADZONE {
+			return true
+		}
+	}
+	return false
+}
+
+// isGamepadButtonJustPressed reports whether the given standard layout button
+// was pressed this frame on any connected gamepad. gamepads without a standard
+// layout use their first button instead.
+func isGamepadButtonJustPressed(button ebiten.StandardGamepadButton) bool {
+	for _, id := range gamepadIDs() {
+		if ebiten.IsStandardGamepadLayoutAvailable(id) {
+			if inpututil.IsStandardGamepadButtonJustPressed(id, button) {
+				return true
+			}
+		} else if inpututil.IsGamepadButtonJustPressed(id, ebiten.GamepadButton0) {
+			return true
+		}
+	}
+	return false
+}
+
+// isGamepadDirectionRepeated reports whether the D-pad was pressed in the
+// given direction this frame on any connected gamepad, or has been held long
+// enough to repeat, for menu navigation
+func isGamepadDirectionRepeated(direction Vec2) bool {
+	for _, id := range gamepadIDs() {
+		if ebiten.IsStandardGamepadLayoutAvailable(id) && isRepeat(inpututil.StandardGamepadButtonPressDuration(id, dpadButton(direction))) {
+			return true
+		}
+	}
+	return false
+}
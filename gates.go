@@ -0,0 +1,82 @@
+package main
+
+import "github.com/hajimehoshi/ebiten/v2"
+
+// GATE_PERIOD is the number of frames a gate stays closed, and then open, for
+// when the level doesn't set one with a ";gate=" header
+const GATE_PERIOD = 2 * FPS
+
+// Gate is a wall that opens and closes on a timer. 'T' gates start closed and
+// 't' gates start open, so pairs of them can take turns.
+type Gate struct {
+	position Vec2
+	// period is the number of frames the gate stays closed, then open
+	period int
+	// phase shifts the gate's timer by a number of frames
+	phase int
+}
+
+// NewGate creates a gate at position that opens and closes every period
+// frames, starting open if open is set
+func NewGate(position Vec2, period int, open bool) Gate {
+	gate := Gate{position: position, period: period}
+	if open {
+		gate.phase = period
+	}
+	return gate
+}
+
+// closed reports whether the gate's timer has it closed on the given frame
+func (gate Gate) closed(frame int) bool {
+	return (frame+gate.phase)/gate.period%2 == 0
+}
+
+// gateAt reports whether there is a gate at the position
+func (level *Level) gateAt(position Vec2) bool {
+	for _, gate := range level.gates {
+		if gate.position == position {
+			return true
+		}
+	}
+	return false
+}
+
+// updateGates opens and closes each gate by setting its wall to match the
+// gate's timer. a gate due to close waits while a snake or ghost is on it,
+// so nothing is ever shut inside a wall.
+func updateGates(state *State) {
+	for _, gate := range state.level.gates {
+		closed := gate.closed(state.frame)
+		if closed && isOccupied(state, gate.position) {
+			continue
+		}
+		state.level.walls[gate.position.y][gate.position.x] = closed
+	}
+}
+
+// isOccupied reports whether any part of a snake or any ghost is on position
+func isOccupied(state *State, position Vec2) bool {
+	for _, snake := range state.snakes {
+		for _, segment := range snake.body {
+			if segment == position {
+				return true
+			}
+		}
+	}
+	return state.level.ghostAt(position)
+}
+
+// drawGates draws closed gates as solid cells and open ones as outlines. gates
+// are left out of the wall layer so they can change without redrawing it.
+func drawGates(screen *ebiten.Image, state *State) {
+	for _, gate := range state.level.gates {
+		if !isVisible(state, gate.position) {
+			continue
+		}
+		if state.level.walls[gate.position.y][gate.position.x] {
+			drawCell(screen, state, gate.position, state.config.palette.wall)
+		} else {
+			drawCellOutline(screen, state, gate.position, dimColor(state.config.palette.wall, 0.5))
+		}
+	}
+}
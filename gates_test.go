@@ -0,0 +1,51 @@
+package main
+
+import "testing"
+
+func TestGateClosed(t *testing.T) {
+	tests := []struct {
+		open  bool
+		frame int
+		want  bool
+	}{
+		{false, 0, true},
+		{false, 9, true},
+		{false, 10, false},
+		{false, 19, false},
+		{false, 20, true},
+		{true, 0, false},
+		{true, 9, false},
+		{true, 10, true},
+		{true, 20, false},
+	}
+	for _, test := range tests {
+		gate := NewGate(Vec2{}, 10, test.open)
+		if got := gate.closed(test.frame); got != test.want {
+			t.Errorf("gate starting open %v: closed(%d) = %v, want %v", test.open, test.frame, got, test.want)
+		}
+	}
+}
+
+func TestGateCollision(t *testing.T) {
+	tests := []struct {
+		name      string
+		gate      string
+		wantLives int
+	}{
+		{"closed", "T", 2},
+		{"open", "t", 3},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			state := newTestState(t, ";gate=5\n#######\n#S."+test.gate+".E#\n#F....#\n#######\n")
+			lives := state.lives
+			state.Step(Vec2{x: 1, y: 0})
+			for i := 0; i < 1000 && state.lives == lives && state.snakes[0].getHead() != (Vec2{x: 4, y: 1}); i++ {
+				state.Step(Vec2{})
+			}
+			if state.lives != test.wantLives {
+				t.Errorf("lives = %d after running into a gate %s for the first %d frames, want %d", state.lives, test.name, 5*FPS, test.wantLives)
+			}
+		})
+	}
+}
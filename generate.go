@@ -0,0 +1,95 @@
+package main
+
+import "math/rand"
+
+const (
+	GENERATED_WIDTH   = 63
+	GENERATED_HEIGHT  = 23
+	GENERATED_FOODS   = 10
+	GENERATED_PELLETS = 2
+)
+
+// GenerateLevel builds a random maze using a recursive backtracker. the maze is
+// a spanning tree over the cells at odd coordinates, so every open cell,
+// including the exit, is reachable from the entrance. even sizes are shrunk by
+// one so the maze stays enclosed by walls, and generators with the same seed
+// always produce the same level.
+func GenerateLevel(width, height int, rng *rand.Rand) Level {
+	if width%2 == 0 {
+		width--
+	}
+	if height%2 == 0 {
+		height--
+	}
+	if width < 5 {
+		width = 5
+	}
+	if height < 5 {
+		height = 5
+	}
+
+	level := Level{
+		width:  width,
+		height: height,
+		walls:  make(Slice[Slice[bool]], height),
+		foods:  Slice[Food]{},
+		ghosts: Slice[Ghost]{},
+	}
+	for y := range level.walls {
+		level.walls[y] = make(Slice[bool], width)
+		for x := range level.walls[y] {
+			level.walls[y][x] = true
+		}
+	}
+
+	// carve passages by walking to a random unvisited cell two steps away and
+	// backtracking when there are none left
+	start := Vec2{x: 1, y: 1}
+	level.walls[start.y][start.x] = false
+	stack := NewSlice(start)
+	for len(stack) > 0 {
+		current := stack[len(stack)-1]
+
+		unvisited := NewSlice[Vec2]()
+		for _, direction := range directions {
+			next := current.add(direction.scale(2))
+			if next.x > 0 && next.x < width-1 && next.y > 0 && next.y < height-1 && level.walls[next.y][next.x] {
+				unvisited = append(unvisited, next)
+			}
+		}
+		if len(unvisited) == 0 {
+			stack = stack[:len(stack)-1]
+			continue
+		}
+
+		next := unvisited[rng.Intn(len(unvisited))]
+		level.walls[(current.y+next.y)/2][(current.x+next.x)/2] = false
+		level.walls[next.y][next.x] = false
+		stack = append(stack, next)
+	}
+
+	level.entrance = start
+	level.exit = Vec2{x: width - 2, y: height - 2}
+
+	// scatter food and power pellets over the remaining open cells
+	open := NewSlice[Vec2]()
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			p := Vec2{x: x, y: y}
+			if !level.walls[y][x] && p != level.entrance && p != level.exit {
+				open = append(open, p)
+			}
+		}
+	}
+	rng.Shuffle(len(open), func(i, j int) {
+		open[i], open[j] = open[j], open[i]
+	})
+	for i := 0; i < GENERATED_FOODS && i < len(open); i++ {
+		level.foods = append(level.foods, Food{position: open[i], kind: FoodNormal})
+	}
+	for i := GENERATED_FOODS; i < GENERATED_FOODS+GENERATED_PELLETS && i < len(open); i++ {
+		level.foods = append(level.foods, Food{position: open[i], kind: FoodPower})
+	}
+
+	return level
+}
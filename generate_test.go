@@ -0,0 +1,39 @@
+package main
+
+import (
+	"math/rand"
+	"testing"
+)
+
+func TestGenerateLevel(t *testing.T) {
+	sizes := []Vec2{
+		{x: GENERATED_WIDTH, y: GENERATED_HEIGHT},
+		{x: 10, y: 8},
+		{x: 1, y: 1},
+	}
+	for _, size := range sizes {
+		for seed := int64(1); seed <= 20; seed++ {
+			level := GenerateLevel(size.x, size.y, rand.New(rand.NewSource(seed)))
+			parsed, err := parseLevel(1, level.String())
+			if err != nil {
+				t.Fatalf("size %v seed %d: %v\n%s", size, seed, err, level.String())
+			}
+			if !parsed.IsSolvable() {
+				t.Errorf("size %v seed %d: level isn't solvable\n%s", size, seed, level.String())
+			}
+
+			again := GenerateLevel(size.x, size.y, rand.New(rand.NewSource(seed)))
+			if again.String() != level.String() {
+				t.Errorf("size %v seed %d: same seed gave different levels\n%s\n%s", size, seed, level.String(), again.String())
+			}
+		}
+	}
+}
+
+func TestGenerateLevelSeedsDiffer(t *testing.T) {
+	first := GenerateLevel(GENERATED_WIDTH, GENERATED_HEIGHT, rand.New(rand.NewSource(1)))
+	second := GenerateLevel(GENERATED_WIDTH, GENERATED_HEIGHT, rand.New(rand.NewSource(2)))
+	if first.String() == second.String() {
+		t.Errorf("seeds 1 and 2 gave the same level\n%s", first.String())
+	}
+}
@@ -0,0 +1,88 @@
+package main
+
+import "github.com/hajimehoshi/ebiten/v2"
+
+// GHOST_RUN_TAIL is how many cells of the best run's path are drawn behind its
+// head
+const GHOST_RUN_TAIL = 3
+
+// Run is the path player one's head took through a level, one position for
+// each frame since the level started
+type Run struct {
+	// seed is the seed the level was played with, which tells apart generated
+	// mazes that share a level id
+	seed  int
+	heads Slice[Vec2]
+}
+
+// recordRun adds where player one's head is to the run through the current
+// level. it's called at the end of every update, so run[i] is where the head
+// was i+1 frames into the level.
+func recordRun(state *State) {
+	if state.config.mode == ModeVersus {
+		return
+	}
+	state.run = append(state.run, state.snakes[0].getHead())
+}
+
+// ghostRunPath returns the cells of the best run to draw on the current frame,
+// head first, followed by up to GHOST_RUN_TAIL cells it had just come from.
+// it's empty when there's no best run, or it had already finished by this far
+// into the level.
+func ghostRunPath(state *State) Slice[Vec2] {
+	path := NewSlice[Vec2]()
+	frame := state.frame - state.levelStartFrame - 1
+	if frame < 0 || frame >= len(state.bestRun) {
+		return path
+	}
+	// the head sits on each cell for several frames between moves
+	for i := frame; i >= 0 && len(path) <= GHOST_RUN_TAIL; i-- {
+		if len(path) == 0 || path[len(path)-1] != state.bestRun[i] {
+			path = append(path, state.bestRun[i])
+		}
+	}
+	return path
+}
+
+// drawGhostRun draws the best run through the level as a see-through snake
+// racing the live one, fading toward its tail
+func drawGhostRun(screen *ebiten.Image, state *State) {
+	path := ghostRunPath(state)
+	for i, p := range path {
+		if !isVisible(state, p) {
+			continue
+		}
+		// dimming the alpha along with the color keeps it premultiplied
+		fade := 0.4 * float64(len(path)-i) / float64(len(path))
+		ghostColor := dimColor(state.config.palette.snake, fade)
+		ghostColor.A = uint8(255 * fade)
+		drawCell(screen, state, p, ghostColor)
+	}
+}
+
+// collectBestRun keeps the run through the level that was just finished when
+// it was faster than the best so far. like the high score, versus games,
+// replays, and runs steered by the autopilot don't count.
+func (game *Game) collectBestRun() {
+	state := &game.state
+	if state.status != StatusLevelComplete && state.status != StatusWon {
+		return
+	}
+	if state.config.mode == ModeVersus || game.replay != nil || game.autopilot || len(state.run) == 0 {
+		return
+	}
+	best, ok := game.bestRuns[state.level.id]
+	if ok && best.seed == state.config.seed && len(best.heads) <= len(state.run) {
+		return
+	}
+	game.bestRuns[state.level.id] = Run{seed: state.config.seed, heads: state.run}
+}
+
+// loadBestRun sets the best run through the current level, if there is one,
+// as the one for the snake to race
+func (game *Game) loadBestRun() {
+	game.state.bestRun = nil
+	if run, ok := game.bestRuns[game.state.level.id]; ok && run.seed == game.state.config.seed {
+		game.state.bestRun = run.heads
+	}
+}
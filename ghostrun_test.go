@@ -0,0 +1,106 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+const GHOST_RUN_LEVEL = "#######\n#S....#\n#F...E#\n#######\n"
+
+// playRun plays the first of two levels, waiting for the given number of
+// frames before taking the turns in order, and returns the game once the level
+// is complete
+func playRun(t *testing.T, wait int, turns ...Vec2) *Game {
+	t.Helper()
+	game := &Game{
+		state:    newTestState(t, GHOST_RUN_LEVEL, GHOST_RUN_LEVEL),
+		bestRuns: map[int]Run{},
+	}
+	for i := 0; i < wait; i++ {
+		game.state.Step(Vec2{})
+	}
+	for _, turn := range turns {
+		stepUntilMoved(t, &game.state, turn)
+	}
+	for i := 0; i < 1000 && game.state.status == StatusPlaying; i++ {
+		game.state.Step(Vec2{})
+	}
+	if game.state.status != StatusLevelComplete {
+		t.Fatalf("status = %v, want level complete", game.state.status)
+	}
+	return game
+}
+
+func TestBestRunSaved(t *testing.T) {
+	fast := playRun(t, 0, Vec2{x: 0, y: 1}, Vec2{x: 1, y: 0})
+	fast.collectBestRun()
+	best, ok := fast.bestRuns[1]
+	if !ok || !reflect.DeepEqual(best.heads, fast.state.run) {
+		t.Fatalf("best run = %v, want the run just finished", best.heads)
+	}
+	if len(best.heads) != fast.state.frame {
+		t.Errorf("best run has %d heads after %d frames, want one a frame", len(best.heads), fast.state.frame)
+	}
+
+	// a slower run through the same level doesn't replace it
+	slow := playRun(t, FPS, Vec2{x: 1, y: 0}, Vec2{}, Vec2{}, Vec2{x: 0, y: 1}, Vec2{x: 1, y: 0})
+	slow.bestRuns = fast.bestRuns
+	slow.collectBestRun()
+	if !reflect.DeepEqual(slow.bestRuns[1].heads, best.heads) {
+		t.Error("a slower run replaced the best one")
+	}
+
+	// and a faster one does
+	fast.bestRuns = map[int]Run{1: {seed: slow.state.config.seed, heads: slow.state.run}}
+	fast.collectBestRun()
+	if !reflect.DeepEqual(fast.bestRuns[1].heads, fast.state.run) {
+		t.Error("a faster run didn't replace the best one")
+	}
+}
+
+func TestBestRunReplaysInSync(t *testing.T) {
+	first := playRun(t, 0, Vec2{x: 0, y: 1}, Vec2{x: 1, y: 0})
+	first.collectBestRun()
+
+	game := &Game{
+		state:    newTestState(t, GHOST_RUN_LEVEL, GHOST_RUN_LEVEL),
+		bestRuns: first.bestRuns,
+	}
+	game.loadBestRun()
+	if len(game.state.bestRun) == 0 {
+		t.Fatal("the best run wasn't loaded")
+	}
+
+	// playing the same turns on the same frames keeps the live snake under
+	// the ghost the whole way
+	state := &game.state
+	step := func(direction Vec2) {
+		state.Step(direction)
+		path := ghostRunPath(state)
+		if len(path) == 0 {
+			t.Fatalf("no ghost on frame %d of a %d frame run", state.frame, len(state.bestRun))
+		}
+		if path[0] != state.snakes[0].getHead() {
+			t.Fatalf("ghost at %v on frame %d, want it on the live head at %v", path[0], state.frame, state.snakes[0].getHead())
+		}
+	}
+	for _, turn := range []Vec2{{x: 0, y: 1}, {x: 1, y: 0}} {
+		head := state.snakes[0].getHead()
+		step(turn)
+		for i := 0; i < 100 && state.snakes[0].getHead() == head; i++ {
+			step(Vec2{})
+		}
+	}
+	for i := 0; i < 1000 && state.status == StatusPlaying; i++ {
+		step(Vec2{})
+	}
+	if state.frame != len(state.bestRun) {
+		t.Errorf("level finished on frame %d, want %d like the best run", state.frame, len(state.bestRun))
+	}
+
+	// once the best run has finished the ghost is gone
+	state.frame++
+	if path := ghostRunPath(state); len(path) != 0 {
+		t.Errorf("ghost path = %v after the best run finished, want none", path)
+	}
+}
@@ -2,12 +2,14 @@ module pacsnek
 
 go 1.19
 
+require github.com/hajimehoshi/ebiten/v2 v2.7.8
+
 require (
 	github.com/ebitengine/gomobile v0.0.0-20240518074828-e86332849895 // indirect
 	github.com/ebitengine/hideconsole v1.0.0 // indirect
+	github.com/ebitengine/oto/v3 v3.2.0 // indirect
 	github.com/ebitengine/purego v0.7.0 // indirect
 	github.com/go-text/typesetting v0.1.1-0.20240325125605-c7936fe59984 // indirect
-	github.com/hajimehoshi/ebiten/v2 v2.7.8 // indirect
 	github.com/jezek/xgb v1.1.1 // indirect
 	golang.org/x/image v0.19.0 // indirect
 	golang.org/x/sync v0.8.0 // indirect
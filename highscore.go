@@ -0,0 +1,67 @@
+package main
+
+import (
+	"errors"
+	"io/fs"
+	"log"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+const HIGH_SCORE_FILE = "highscore.txt"
+
+// configDir returns the writable directory where pacsnek keeps its files. the
+// embedded assets are read-only, so anything persisted goes here instead.
+func configDir() (string, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "pacsnek"), nil
+}
+
+// loadHighScore reads the saved high score from the config directory. a missing
+// file means the game hasn't been played yet, so the high score is 0.
+func loadHighScore() (int, error) {
+	dir, err := configDir()
+	if err != nil {
+		return 0, err
+	}
+
+	content, err := os.ReadFile(filepath.Join(dir, HIGH_SCORE_FILE))
+	if errors.Is(err, fs.ErrNotExist) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	return strconv.Atoi(strings.TrimSpace(string(content)))
+}
+
+// saveHighScore writes the given score to the config directory, creating the
+// directory if needed
+func saveHighScore(score int) error {
+	dir, err := configDir()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(dir, HIGH_SCORE_FILE), []byte(strconv.Itoa(score)), 0o644)
+}
+
+// recordHighScore updates the high score if the current score beats it. a
+// failed save is logged rather than interrupting the game.
+func (game *Game) recordHighScore() {
+	if game.state.score <= game.highScore {
+		return
+	}
+	game.highScore = game.state.score
+	if err := saveHighScore(game.highScore); err != nil {
+		log.Printf("saving high score: %v", err)
+	}
+}
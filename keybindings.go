@@ -0,0 +1,128 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/hajimehoshi/ebiten/v2"
+)
+
+// Action is something the player does that can be bound to a key
+type Action int
+
+const (
+	ActionUp Action = iota
+	ActionDown
+	ActionLeft
+	ActionRight
+	ActionStart
+	ActionRestart
+	ActionPause
+	ActionDig
+	ActionRewind
+)
+
+// actions lists every action in the order they are written to the key
+// bindings file. the first four are in the same order as directions.
+var actions = [9]Action{ActionUp, ActionDown, ActionLeft, ActionRight, ActionStart, ActionRestart, ActionPause, ActionDig, ActionRewind}
+
+func (action Action) String() string {
+	switch action {
+	case ActionUp:
+		return "up"
+	case ActionDown:
+		return "down"
+	case ActionLeft:
+		return "left"
+	case ActionRight:
+		return "right"
+	case ActionStart:
+		return "start"
+	case ActionRestart:
+		return "restart"
+	case ActionPause:
+		return "pause"
+	case ActionDig:
+		return "dig"
+	case ActionRewind:
+		return "rewind"
+	}
+	return "unknown"
+}
+
+// reservedKeys are the keys that toggle muting, the minimap, and autopilot, or
+// open the options, editor, and difficulty from the menu, whatever else is
+// pressed. binding an action to one would set both off at once.
+var reservedKeys = [5]ebiten.Key{ebiten.KeyM, ebiten.KeyN, ebiten.KeyO, ebiten.KeyE, ebiten.KeyD}
+
+// KeyBindings maps each action to the key that performs it
+type KeyBindings struct {
+	keys [9]ebiten.Key
+}
+
+// DefaultKeyBindings returns the keys the game ships with
+func DefaultKeyBindings() KeyBindings {
+	var bindings KeyBindings
+	bindings.keys[ActionUp] = ebiten.KeyArrowUp
+	bindings.keys[ActionDown] = ebiten.KeyArrowDown
+	bindings.keys[ActionLeft] = ebiten.KeyArrowLeft
+	bindings.keys[ActionRight] = ebiten.KeyArrowRight
+	bindings.keys[ActionStart] = ebiten.KeySpace
+	bindings.keys[ActionRestart] = ebiten.KeyR
+	bindings.keys[ActionPause] = ebiten.KeyP
+	bindings.keys[ActionDig] = ebiten.KeyShift
+	bindings.keys[ActionRewind] = ebiten.KeyZ
+	return bindings
+}
+
+// key returns the key bound to the action
+func (bindings KeyBindings) key(action Action) ebiten.Key {
+	return bindings.keys[action]
+}
+
+// name returns the key bound to the action as it's shown in on screen prompts
+func (bindings KeyBindings) name(action Action) string {
+	return strings.ToUpper(bindings.key(action).String())
+}
+
+// directionKeys returns the keys bound to each of the directions, in the same
+// order as directions
+func (bindings KeyBindings) directionKeys() [4]ebiten.Key {
+	return [4]ebiten.Key{bindings.keys[ActionUp], bindings.keys[ActionDown], bindings.keys[ActionLeft], bindings.keys[ActionRight]}
+}
+
+// names returns the name of the key bound to each action, by action name,
+// using ebitengine's key names
+func (bindings KeyBindings) names() map[string]string {
+	names := make(map[string]string, len(actions))
+	for _, action := range actions {
+		names[action.String()] = bindings.key(action).String()
+	}
+	return names
+}
+
+// withNames returns the bindings with each action in names bound to the named
+// key. actions missing from names keep the key they had, and none can be bound
+// to one of the reservedKeys.
+func (bindings KeyBindings) withNames(names map[string]string) (KeyBindings, error) {
+	for name, keyName := range names {
+		found := false
+		for _, action := range actions {
+			if action.String() == name {
+				if err := bindings.keys[action].UnmarshalText([]byte(keyName)); err != nil {
+					return KeyBindings{}, fmt.Errorf("unknown key %q for %s", keyName, name)
+				}
+				for _, reserved := range reservedKeys {
+					if bindings.keys[action] == reserved {
+						return KeyBindings{}, fmt.Errorf("key %q for %s is reserved", keyName, name)
+					}
+				}
+				found = true
+			}
+		}
+		if !found {
+			return KeyBindings{}, fmt.Errorf("unknown action %q", name)
+		}
+	}
+	return bindings, nil
+}
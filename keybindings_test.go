@@ -0,0 +1,47 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/hajimehoshi/ebiten/v2"
+)
+
+func TestWithNames(t *testing.T) {
+	bindings, err := DefaultKeyBindings().withNames(map[string]string{"up": "W", "dig": "X"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bindings.key(ActionUp) != ebiten.KeyW || bindings.key(ActionDig) != ebiten.KeyX {
+		t.Errorf("up and dig bound to %v and %v, want W and X", bindings.key(ActionUp), bindings.key(ActionDig))
+	}
+	if bindings.key(ActionDown) != ebiten.KeyArrowDown {
+		t.Errorf("down bound to %v, want it left on the default", bindings.key(ActionDown))
+	}
+}
+
+func TestWithNamesErrors(t *testing.T) {
+	tests := []struct {
+		name  string
+		names map[string]string
+		want  string
+	}{
+		{"unknown action", map[string]string{"jump": "Space"}, `unknown action "jump"`},
+		{"unknown key", map[string]string{"up": "Banana"}, `unknown key "Banana" for up`},
+		{"mute", map[string]string{"up": "M"}, `key "M" for up is reserved`},
+		{"minimap", map[string]string{"start": "N"}, `key "N" for start is reserved`},
+		{"options", map[string]string{"pause": "O"}, `key "O" for pause is reserved`},
+		{"editor", map[string]string{"dig": "E"}, `key "E" for dig is reserved`},
+		{"difficulty", map[string]string{"rewind": "D"}, `key "D" for rewind is reserved`},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := DefaultKeyBindings().withNames(test.names)
+			if err == nil {
+				t.Fatal("withNames returned no error")
+			}
+			if err.Error() != test.want {
+				t.Errorf("error = %q, want %q", err, test.want)
+			}
+		})
+	}
+}
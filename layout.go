@@ -0,0 +1,54 @@
+package main
+
+import "github.com/hajimehoshi/ebiten/v2"
+
+// Layout is the size of the game screen in pixels, and the size of a level
+// cell scaled to match. it's recomputed whenever the window is resized.
+type Layout struct {
+	width    int
+	height   int
+	cellSize int
+}
+
+// NewLayout scales config.gridSize by how much bigger or smaller the screen is
+// than config.screenWidth by config.screenHeight. the smaller of the two
+// ratios is used so cells stay square and a wider or taller window shows more
+// of the level instead of stretching it.
+func NewLayout(width int, height int, config Config) Layout {
+	scale := float64(width) / float64(config.screenWidth)
+	if heightScale := float64(height) / float64(config.screenHeight); heightScale < scale {
+		scale = heightScale
+	}
+
+	cellSize := int(float64(config.gridSize) * scale)
+	if cellSize < 1 {
+		cellSize = 1
+	}
+	return Layout{width: width, height: height, cellSize: cellSize}
+}
+
+// viewportWidth returns the number of level columns that fit on screen
+func (layout Layout) viewportWidth() int {
+	return layout.width / layout.cellSize
+}
+
+// viewportHeight returns the number of level rows that fit on screen
+func (layout Layout) viewportHeight() int {
+	return layout.height / layout.cellSize
+}
+
+// toggleFullscreen switches between fullscreen and a window. the window size
+// is remembered on the way in and restored on the way out, and Layout rescales
+// the cells to whichever size results.
+func (game *Game) toggleFullscreen() {
+	if ebiten.IsFullscreen() {
+		ebiten.SetFullscreen(false)
+		if game.windowedWidth > 0 && game.windowedHeight > 0 {
+			ebiten.SetWindowSize(game.windowedWidth, game.windowedHeight)
+		}
+		return
+	}
+
+	game.windowedWidth, game.windowedHeight = ebiten.WindowSize()
+	ebiten.SetFullscreen(true)
+}
@@ -3,24 +3,49 @@ package main
 import (
 	"bytes"
 	"embed"
+	"errors"
+	"flag"
 	"fmt"
 	"image/color"
+	"io/fs"
 	"log"
+	"math"
+	"math/rand"
+	"os"
+	"sort"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/inpututil"
 	"github.com/hajimehoshi/ebiten/v2/text/v2"
 	"github.com/hajimehoshi/ebiten/v2/vector"
 )
 
 const (
-	SCREEN_WIDTH   = 640
-	SCREEN_HEIGHT  = 480
-	GRID_SIZE      = 20
-	VIEWPORT_WIDTH = SCREEN_WIDTH / GRID_SIZE
-	TITLE          = "PACSNEK MAZE"
-	POWERUP_TIME   = 300 // 5 seconds @ 60fps
+	SCREEN_WIDTH  = 640 // default window size, changed with -width and -height
+	SCREEN_HEIGHT = 480
+	TITLE         = "PACSNEK MAZE"
+	FPS           = 60 // ticks per second
+
+	INPUT_BUFFER_SIZE = 2  // turns that can be queued between steps
+	DYING_FRAMES      = 30 // length of the death animation before game over
+	RESTART_DELAY     = 30 // frames the end screen ignores restart for, so it can be read
+
+	POWER_UP_WARNING_FRAMES = 60 // a power-up flashes for this long before it ends
+	LEVEL_BANNER_FRAMES     = 90 // the level's title is shown for this long as it starts
+	// ZEN_STUCK_STEPS is how many steps a snake boxed in during a zen game
+	// waits, in case a gate opens, before it's put back at the entrance
+	ZEN_STUCK_STEPS = 5
+)
+
+// bits returned by Level.wallNeighbors
+const (
+	WALL_UP = 1 << iota
+	WALL_DOWN
+	WALL_LEFT
+	WALL_RIGHT
 )
 
 type Slice[E any] []E
@@ -29,8 +54,13 @@ func NewSlice[E any](elements ...E) Slice[E] {
 	return elements
 }
 
+// removeAt returns a copy of the slice without the element at the given index.
+// a fresh backing array is allocated so the original slice is never mutated,
+// which keeps it safe to call while ranging over the slice.
 func (slice Slice[E]) removeAt(index int) Slice[E] {
-	return append(slice[:index], slice[index+1:]...)
+	result := make(Slice[E], 0, len(slice)-1)
+	result = append(result, slice[:index]...)
+	return append(result, slice[index+1:]...)
 }
 
 type Status int
@@ -38,19 +68,26 @@ type Status int
 const (
 	StatusStarted Status = iota
 	StatusPlaying
+	StatusPaused
+	// StatusDying plays the death animation, then becomes StatusLost
+	StatusDying
+	// StatusLevelComplete waits between levels for the player to continue
+	StatusLevelComplete
 	StatusLost
 	StatusWon
+	StatusEditing
+	// StatusOptions shows the options screen opened from the menu
+	StatusOptions
 )
 
 //go:embed assets/*
 var assets embed.FS
 
-// global font
-var font Font = NewFont()
-
 type Font struct {
 	regular text.GoTextFace
 	small   text.GoTextFace
+	// tiny is used for long status lines such as the editor's
+	tiny text.GoTextFace
 }
 
 // NewFont creates a new Font struct by loading the font from the assets folder
@@ -74,26 +111,65 @@ func NewFont() Font {
 			Source: fontFaceSource,
 			Size:   20,
 		},
+		tiny: text.GoTextFace{
+			Source: fontFaceSource,
+			Size:   10,
+		},
 	}
 }
 
-// state is the global game state, initialized with a new State instance. it
-// holds all the current game information and is updated throughout gameplay.
-var state State = NewState()
-
 // NewState creates and returns a new State instance, initializing the game with
-// default values for a new game session.
-func NewState() State {
-	level := NewLevel(1)
+// default values for a new game session starting at the given level with the
+// given configuration, scaled for its difficulty. it returns an error if that
+// level can't be loaded.
+func NewState(startLevel int, config Config) (State, error) {
+	config = config.difficulty.apply(config)
+	level, err := loadLevel(startLevel, config)
+	if err != nil {
+		return State{}, err
+	}
+	// the levels after the first are loaded now too, so a broken one is
+	// reported before the game starts rather than ending it partway through
+	upcoming := NewSlice[Level]()
+	for id := startLevel + 1; !config.generate; id++ {
+		next, err := loadLevel(id, config)
+		if errors.Is(err, fs.ErrNotExist) {
+			break
+		}
+		if err != nil {
+			return State{}, err
+		}
+		upcoming = append(upcoming, next)
+	}
 
-	return State{
-		status:       StatusStarted,
-		viewportX:    0,
-		level:        level,
-		snake:        NewSnake(level.entrance),
-		score:        0,
-		powerUpTimer: 0,
+	snakes := NewSlice(NewSnake(level.entrance, config.moveInterval))
+	if config.mode == ModeVersus {
+		rival := NewSnake(level.rivalEntrance(), config.moveInterval)
+		rival.player = 1
+		snakes = append(snakes, rival)
 	}
+
+	return State{
+		status:        StatusStarted,
+		viewportX:     0,
+		viewportY:     0,
+		level:         level,
+		upcoming:      upcoming,
+		snakes:        snakes,
+		score:         0,
+		powerUpTimer:  0,
+		lives:         config.startingLives,
+		config:        config,
+		rng:           rand.New(rand.NewSource(int64(config.seed))),
+		layout:        NewLayout(config.screenWidth, config.screenHeight, config),
+		timeRemaining: level.timeLimit * FPS,
+		bannerFrames:  LEVEL_BANNER_FRAMES,
+		bonusTimer:    BONUS_INTERVAL,
+		practice:      config.mode == ModePractice,
+		rewindCharges: REWIND_CHARGES,
+		// sudden death is counted from the start of each level
+		suddenDeathTimer: config.suddenDeathTime,
+	}, nil
 }
 
 // Vec2 represents a 2D vector or point with integer coordinates. it's used
@@ -103,85 +179,517 @@ type Vec2 struct {
 	y int
 }
 
+// add returns the sum of the two vectors, such as the cell one step from a
+// position in a direction
+func (v Vec2) add(other Vec2) Vec2 {
+	return Vec2{x: v.x + other.x, y: v.y + other.y}
+}
+
+// sub returns the vector from other to v
+func (v Vec2) sub(other Vec2) Vec2 {
+	return Vec2{x: v.x - other.x, y: v.y - other.y}
+}
+
+// scale returns the vector multiplied by n along both axes
+func (v Vec2) scale(n int) Vec2 {
+	return Vec2{x: v.x * n, y: v.y * n}
+}
+
+// manhattan returns the number of steps between two positions, ignoring
+// walls and without wrapping around the level's edges
+func (v Vec2) manhattan(other Vec2) int {
+	d := v.sub(other)
+	return abs(d.x) + abs(d.y)
+}
+
 type Snake struct {
-	body                Slice[Vec2]
+	body Slice[Vec2]
+	// player is the index of the snake in State.snakes
+	player int
+	// score counts the points this snake has earned in versus mode. single
+	// player games keep the score on State instead, so it survives respawns.
+	score int
+	// prevDirection is the direction of the last step taken and direction is
+	// the one the next step will take. both are zero until the first input, so
+	// a new snake stays still until the player chooses where to go.
 	prevDirection       Vec2
 	direction           Vec2
 	framesSinceLastMove int
+	moveInterval        int
+	inputQueue          Slice[Vec2]
+	// digging is set while the dig key is held
+	digging bool
+	// trail holds the cells the tail most recently left, newest first, which
+	// are drawn fading out behind the snake
+	trail Slice[Vec2]
+	// stuckSteps counts the zen steps in a row cancelled while boxed in
+	stuckSteps int
 }
 
-func NewSnake(position Vec2) Snake {
+func NewSnake(position Vec2, moveInterval int) Snake {
 	return Snake{
 		body:                NewSlice(position),
-		prevDirection:       Vec2{x: 1, y: 0},
+		player:              0,
+		score:               0,
+		prevDirection:       Vec2{x: 0, y: 0},
 		direction:           Vec2{x: 0, y: 0},
 		framesSinceLastMove: 0,
+		moveInterval:        moveInterval,
+		inputQueue:          NewSlice[Vec2](),
 	}
 }
 
 // createHead calculates the new position for the snake's head based on its
 // current position and direction. it wraps around the level boundaries to
-// create a toroidal world effect.
-func (snake *Snake) createHead() Vec2 {
-	head := snake.getHead()
-	prev := snake.prevDirection
-	height := state.level.height
-	width := state.level.width
-	return Vec2{
-		x: (head.x + prev.x + width) % width,
-		y: (head.y + prev.y + height) % height,
-	}
+// create a toroidal world effect, unless the level has solid edges, and a head
+// that lands on a portal comes out of its partner instead.
+func (snake *Snake) createHead(level *Level) Vec2 {
+	return level.step(snake.getHead(), snake.prevDirection)
 }
 
-func (snake *Snake) move() {
-	// only move every 10 frames
+func (snake *Snake) move(state *State) {
+	// only move every moveInterval frames, or less often in slow motion
 	snake.framesSinceLastMove += 1
-	if snake.framesSinceLastMove < 10 {
+	if snake.framesSinceLastMove < snake.effectiveMoveInterval(state) {
 		return
 	}
 	snake.framesSinceLastMove = 0
 
+	// take the next buffered turn, if any
+	if len(snake.inputQueue) > 0 {
+		snake.direction = snake.inputQueue[0]
+		snake.inputQueue = snake.inputQueue.removeAt(0)
+	}
+
+	// an arrow tile under the head overrides the player's choice, though it
+	// still can't turn the snake back on itself
+	if forced, ok := state.level.arrows[snake.getHead()]; ok {
+		snake.direction = forced
+	}
+
+	// the snake waits where it is until the player gives it a first direction
+	if snake.direction == (Vec2{}) {
+		return
+	}
+
+	// a turn back onto the neck is ignored and the snake keeps going straight.
+	// prevDirection is still zero before the first step, and a new snake is a
+	// single cell, so whichever way it's first sent is safe.
 	if snake.direction.x != -snake.prevDirection.x || snake.direction.y != -snake.prevDirection.y {
 		snake.prevDirection = snake.direction
 	}
 
-	newHead := snake.createHead()
+	// a head teleported by a portal is checked where it comes out, so a partner
+	// portal covered by the snake's own body is a collision like any other
+	newHead := snake.createHead(&state.level)
+
+	offEdge := state.level.crossesEdge(snake.getHead(), snake.prevDirection)
+	if offEdge || snake.checkCollision(state, newHead) {
+		// in zen mode the step is cancelled instead, and the snake waits
+		// facing whatever it ran into until it's turned. one with nowhere
+		// to turn, like at the end of a dead end, would wait forever, so
+		// it's put back at the entrance after ZEN_STUCK_STEPS.
+		if state.config.mode == ModeZen {
+			snake.stuckSteps += 1
+			if !snake.isBoxedIn(state) {
+				snake.stuckSteps = 0
+			}
+			if snake.stuckSteps >= ZEN_STUCK_STEPS {
+				respawnSnake(state)
+			}
+			return
+		}
+		loseLife(state, snake)
+		// the crash is shown with the head where it hit, but nothing else
+		// about the step happens, so a collision on the exit can't also
+		// finish the level. a head that went off an edge stays put.
+		if state.status == StatusDying && !offEdge {
+			snake.prepend(newHead)
+			snake.removeLastSegment()
+		}
+		return
+	}
+
+	// only steps that happen are kept for rewinding, so a snake held against
+	// a wall in zen mode doesn't fill the history with the same frame
+	if snake.player == 0 {
+		takeSnapshot(state)
+	}
 
-	snake.checkCollision(newHead)
+	if state.level.walls[newHead.y][newHead.x] && !state.noclip {
+		dig(state, newHead)
+	}
 
 	snake.prepend(newHead)
 
-	if newHead == state.level.exit {
+	eatGhosts(state)
+	snake.eatDigPickup(state)
+	snake.eatSlowPickup(state)
+
+	// eating, or trimming the tail when there's nothing to eat, finishes the
+	// step before the exit is checked, so the snake is always a consistent
+	// length when it reaches the exit. checkCollision has already ruled out
+	// every cell of the body, so no two segments share a cell either.
+	length := len(snake.body)
+	snake.eatFood(state)
+	grew := len(snake.body) == length // the tail wasn't trimmed
+
+	// a snake that grows to fill every cell it can reach has nowhere left to
+	// go, which is the best possible finish rather than a crash. an endless
+	// game that has run out of room for new food is just as finished.
+	outOfFood := state.config.mode == ModeEndless && len(state.level.scoringFoods()) == 0
+	if grew && state.config.mode != ModeVersus && (outOfFood || isLevelFilled(state, snake)) {
+		state.perfect = true
 		state.status = StatusWon
+		state.earnWin()
+		state.playSound(SoundWin)
 		return
 	}
 
-	snake.eatFood()
+	if newHead == state.level.exit && state.config.mode != ModeEndless && !state.level.exitLocked() {
+		if state.config.mode == ModeVersus {
+			// the first player to the exit wins the race
+			state.winner = snake.player
+			state.status = StatusWon
+			state.playSound(SoundWin)
+			return
+		}
+		completeLevel(state)
+	}
 }
 
-func (snake *Snake) checkCollision(head Vec2) {
-	if state.level.walls[head.y][head.x] {
-		state.status = StatusLost
+// isBoxedIn reports whether every way the snake is allowed to turn would run
+// into something. turning back onto the neck isn't allowed, so it doesn't
+// count as a way out.
+func (snake *Snake) isBoxedIn(state *State) bool {
+	head := snake.getHead()
+	for _, direction := range directions {
+		if direction.x == -snake.prevDirection.x && direction.y == -snake.prevDirection.y {
+			continue
+		}
+		if !state.level.crossesEdge(head, direction) && !snake.checkCollision(state, state.level.step(head, direction)) {
+			return false
+		}
+	}
+	return true
+}
+
+// queueDirection buffers a turn to be taken on one of the next steps so quick
+// successive key presses aren't lost between moves. directions that repeat or
+// reverse the last queued turn are ignored, as are turns past the buffer size.
+// the reverse guard is checked again against prevDirection when the turn is
+// consumed in move.
+func (snake *Snake) queueDirection(direction Vec2) {
+	last := snake.prevDirection
+	if len(snake.inputQueue) > 0 {
+		last = snake.inputQueue[len(snake.inputQueue)-1]
+	}
+	if direction == last || (direction.x == -last.x && direction.y == -last.y) {
+		return
+	}
+	if len(snake.inputQueue) >= INPUT_BUFFER_SIZE {
 		return
 	}
-	for _, s := range snake.getTail() {
+	snake.inputQueue = append(snake.inputQueue, direction)
+}
+
+// checkCollision reports whether the given head position would hit a wall it
+// can't dig through, a ghost while no power-up is active, the snake's own
+// body, or any part of the other player's snake. with noclip on only ghosts
+// count.
+func (snake *Snake) checkCollision(state *State, head Vec2) bool {
+	if state.noclip {
+		return state.powerUpTimer == 0 && state.level.ghostAt(head)
+	}
+	if state.level.walls[head.y][head.x] && !snake.canDig(state) {
+		return true
+	}
+	if state.powerUpTimer == 0 && state.level.ghostAt(head) {
+		return true
+	}
+	// the whole body is checked, head included, since a step into a portal
+	// right next to its partner comes out where the head already is
+	for _, s := range snake.body {
 		if s == head {
-			state.status = StatusLost
-			return
+			return true
+		}
+	}
+	for _, other := range state.snakes {
+		if other.player == snake.player {
+			continue
 		}
+		for _, s := range other.body {
+			if s == head {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// loseLife takes a life from the player after a collision. the snake respawns
+// at the level entrance while lives remain, otherwise the game is lost. in
+// versus mode there are no lives, and the player that collided is eliminated,
+// handing the win to the other. practice games respawn without losing a life.
+func loseLife(state *State, snake *Snake) {
+	state.playSound(SoundDie)
+	if state.config.mode == ModeVersus {
+		state.winner = 1 - snake.player
+		state.status = StatusWon
+		return
+	}
+
+	if state.practice {
+		// the level is left as it was, so eaten food stays eaten
+		state.deaths++
+		respawnSnake(state)
+		return
+	}
+
+	state.lives--
+	state.deaths++
+	if state.lives <= 0 {
+		startDying(state)
+		return
+	}
+	respawnSnake(state)
+}
+
+// startDying ends the game with the death animation, which runs for
+// DYING_FRAMES before the game is lost
+func startDying(state *State) {
+	state.status = StatusDying
+	state.dyingFrames = DYING_FRAMES
+}
+
+// updateDying counts down the death animation, and loses the game once it
+// has finished
+func updateDying(state *State) {
+	state.dyingFrames -= 1
+	if state.dyingFrames <= 0 {
+		state.dyingFrames = 0
+		state.status = StatusLost
 	}
 }
 
-func (snake *Snake) eatFood() {
-	for i, foodPosition := range state.level.foods {
-		if snake.getHead() == foodPosition {
-			state.level.foods = state.level.foods.removeAt(i)
-			state.score++
-			state.powerUpTimer = POWERUP_TIME
+// respawnSnake replaces the snake with a fresh one at the level entrance. the
+// new snake has its directions reset so it waits for input before moving, but
+// keeps the speed earned from the current score.
+func respawnSnake(state *State) {
+	state.snakes[0] = NewSnake(state.level.entrance, state.config.moveIntervalForScore(state.score))
+}
+
+// eatFood eats the food under the snake's head with the effect of its kind.
+// normal and bonus food score and make the snake grow, a power pellet starts
+// a power-up, and shrink food takes segments off the tail. the tail is trimmed
+// as usual after every kind that doesn't grow the snake, or when there's
+// nothing to eat. a snake too short to lose SHRINK_SEGMENTS and still have a
+// head loses a life instead, the same as a crash.
+func (snake *Snake) eatFood(state *State) {
+	snake.eatBonus(state)
+
+	if i := state.level.foodAt(snake.getHead()); i != -1 {
+		food := state.level.foods[i]
+		state.level.foods = state.level.foods.removeAt(i)
+		state.playSound(SoundEat)
+		switch food.kind {
+		case FoodNormal, FoodBonus:
+			snake.scoreFood(state, food)
 			return
+		case FoodPower:
+			state.powerUpTimer = state.config.powerUpTime
+		case FoodShrink:
+			// the body already holds the new head, which the trim after
+			// shrinking doesn't count against
+			if len(snake.body) <= SHRINK_SEGMENTS+1 {
+				loseLife(state, snake)
+				return
+			}
+			snake.shrink(state, SHRINK_SEGMENTS)
 		}
 	}
+	tail := snake.body[len(snake.body)-1]
 	snake.removeLastSegment()
+	snake.leaveTrail(tail, state.config.trailLength)
+}
+
+// scoreFood credits the snake's player with a food that was just eaten,
+// multiplied by the combo, and speeds the snake up for its new score. an
+// endless game that has run out of food gets a new one.
+func (snake *Snake) scoreFood(state *State, food Food) {
+	state.foodEaten++
+	if state.foodEaten >= BIG_EATER_FOOD {
+		state.earn(AchievementBigEater)
+	}
+	value := state.level.foodValue(food.position)
+	if food.kind == FoodBonus {
+		value = BONUS_POINTS
+	}
+	points := value * state.nextCombo()
+	state.addScore(snake, points)
+	state.popups = append(state.popups, NewPopup(food.position, points))
+	snake.moveInterval = state.config.moveIntervalForScore(state.scoreOf(snake))
+	if state.config.mode == ModeEndless && len(state.level.scoringFoods()) == 0 {
+		spawnFood(state)
+	}
+}
+
+// shrink takes up to count segments off the end of the snake. it stops at two
+// segments, so once the step trims its tail the snake is left with at least
+// its head, though eatFood doesn't shrink a snake that short.
+func (snake *Snake) shrink(state *State, count int) {
+	for i := 0; i < count && len(snake.body) > 2; i++ {
+		tail := snake.body[len(snake.body)-1]
+		snake.removeLastSegment()
+		snake.leaveTrail(tail, state.config.trailLength)
+	}
+}
+
+// leaveTrail adds a cell the tail has just left to the front of the snake's
+// trail, dropping the oldest cells past length
+func (snake *Snake) leaveTrail(position Vec2, length int) {
+	if length <= 0 {
+		return
+	}
+	snake.trail = append(NewSlice(position), snake.trail...)
+	if len(snake.trail) > length {
+		snake.trail = snake.trail[:length]
+	}
+}
+
+// isLevelFilled reports whether the snake covers every cell it could move to,
+// leaving no free cell for its head to go
+func isLevelFilled(state *State, snake *Snake) bool {
+	body := map[Vec2]bool{}
+	for _, segment := range snake.body {
+		body[segment] = true
+	}
+	for position := range state.level.reachableFrom(snake.getHead()) {
+		if !body[position] {
+			return false
+		}
+	}
+	return true
+}
+
+// spawnFood places a new food on a random empty cell the snake can reach.
+// nothing is placed if there is no empty cell left.
+func spawnFood(state *State) {
+	if position, ok := randomEmptyCell(state); ok {
+		state.level.foods = append(state.level.foods, Food{position: position, kind: FoodNormal})
+	}
+}
+
+// randomEmptyCell picks a random open cell that a snake's head can reach and
+// that has nothing on it, chosen with the game's seeded rng so replays pick
+// the same cell. reachability is checked from the heads rather than the
+// entrance, since sudden death can wall a snake off from it. every candidate
+// is found before one is picked, so a full level returns false with ok
+// rather than searching forever.
+func randomEmptyCell(state *State) (position Vec2, ok bool) {
+	level := &state.level
+	occupied := occupiedCells(state)
+
+	// candidates are collected in grid order rather than from the reachable
+	// map, whose iteration order would make the choice differ between runs
+	reached := map[Vec2]bool{}
+	for _, snake := range state.snakes {
+		for position := range level.reachableFrom(snake.getHead()) {
+			reached[position] = true
+		}
+	}
+	candidates := NewSlice[Vec2]()
+	for y := 0; y < level.height; y++ {
+		for x := 0; x < level.width; x++ {
+			position := Vec2{x: x, y: y}
+			if reached[position] && !occupied[position] {
+				candidates = append(candidates, position)
+			}
+		}
+	}
+	if len(candidates) == 0 {
+		return Vec2{}, false
+	}
+	return candidates[state.rng.Intn(len(candidates))], true
+}
+
+// occupiedCells returns the cells with a snake, the exit, or an item on them,
+// along with the cell about to be walled in by sudden death
+func occupiedCells(state *State) map[Vec2]bool {
+	level := &state.level
+	occupied := map[Vec2]bool{level.exit: true}
+	if state.bonusFrames > 0 {
+		occupied[state.bonus] = true
+	}
+	if state.suddenDeathPending {
+		occupied[state.suddenDeathCell] = true
+	}
+	for _, snake := range state.snakes {
+		for _, segment := range snake.body {
+			occupied[segment] = true
+		}
+	}
+	for _, food := range level.foods {
+		occupied[food.position] = true
+	}
+	for _, pickup := range level.digPickups {
+		occupied[pickup] = true
+	}
+	for _, pickup := range level.slowPickups {
+		occupied[pickup] = true
+	}
+	for _, gate := range level.gates {
+		occupied[gate.position] = true
+	}
+	for position := range level.portals {
+		occupied[position] = true
+	}
+	return occupied
+}
+
+// addScore credits points to the given snake's player. versus games score each
+// snake separately, practice games don't score at all, and otherwise the
+// points go to the shared state score.
+func (state *State) addScore(snake *Snake, points int) {
+	if state.practice {
+		return
+	}
+	if state.config.mode == ModeVersus {
+		snake.score += points
+		return
+	}
+	state.score += points
+}
+
+// scoreOf returns the score of the given snake's player
+func (state *State) scoreOf(snake *Snake) int {
+	if state.config.mode == ModeVersus {
+		return snake.score
+	}
+	return state.score
+}
+
+// nextCombo records food being eaten on the current frame and returns the
+// score multiplier for it. eating again within the combo window raises the
+// multiplier by one, up to maxCombo.
+func (state *State) nextCombo() int {
+	if state.combo > 0 && state.frame-state.lastEatFrame <= state.config.comboWindow {
+		state.combo++
+		if state.combo > state.config.maxCombo {
+			state.combo = state.config.maxCombo
+		}
+	} else {
+		state.combo = 1
+	}
+	state.lastEatFrame = state.frame
+	return state.combo
+}
+
+// updateCombo resets the combo once the window to continue it has lapsed
+func updateCombo(state *State) {
+	if state.combo > 0 && state.frame-state.lastEatFrame > state.config.comboWindow {
+		state.combo = 0
+	}
 }
 
 func (snake *Snake) prepend(newHead Vec2) {
@@ -191,8 +699,12 @@ func (snake *Snake) prepend(newHead Vec2) {
 // removeLastSegment removes the last segment of the snake's body. this function
 // is called when the snake moves without eating food, effectively making the
 // snake appear to move forward by removing its tail as a new head segment is
-// added.
+// added. the head is never removed, so getHead always has a segment to return
+// however much the snake shrinks.
 func (snake *Snake) removeLastSegment() {
+	if len(snake.body) <= 1 {
+		return
+	}
 	// remove the last element of the body slice by re-slicing to exclude the
 	// final element
 	snake.body = snake.body[:len(snake.body)-1]
@@ -202,36 +714,264 @@ func (snake *Snake) getHead() Vec2 {
 	return snake.body[0]
 }
 
-func (snake *Snake) getTail() Slice[Vec2] {
-	return snake.body[1:]
+// directions holds the four unit vectors a snake or ghost can step in
+var directions = [4]Vec2{{x: 0, y: -1}, {x: 0, y: 1}, {x: -1, y: 0}, {x: 1, y: 0}}
+
+// Ghost is an enemy that chases the snake through the maze
+type Ghost struct {
+	position            Vec2
+	framesSinceLastMove int
+}
+
+func NewGhost(position Vec2) Ghost {
+	return Ghost{
+		position:            position,
+		framesSinceLastMove: 0,
+	}
+}
+
+// move steps the ghost one cell along the shortest path toward the nearest
+// snake's head every ghostMoveInterval frames
+func (ghost *Ghost) move(state *State) {
+	ghost.framesSinceLastMove += 1
+	if ghost.framesSinceLastMove < state.config.ghostMoveInterval {
+		return
+	}
+	ghost.framesSinceLastMove = 0
+
+	target := state.snakes[0].getHead()
+	for _, snake := range state.snakes[1:] {
+		head := snake.getHead()
+		if state.level.wrappedDistance(ghost.position, head) < state.level.wrappedDistance(ghost.position, target) {
+			target = head
+		}
+	}
+	ghost.position = state.level.nextStepTowards(ghost.position, target)
+}
+
+// moveGhosts advances every ghost and takes a life if one of them catches a
+// snake's head while no power-up is active. while powered up, a ghost that
+// moves onto a head is eaten instead.
+func moveGhosts(state *State) {
+	for i := range state.level.ghosts {
+		state.level.ghosts[i].move(state)
+	}
+	if state.powerUpTimer == 0 {
+		for i := range state.snakes {
+			if state.level.ghostAt(state.snakes[i].getHead()) {
+				loseLife(state, &state.snakes[i])
+				return
+			}
+		}
+	}
+	eatGhosts(state)
+}
+
+// eatGhosts removes every ghost on a snake's head while a power-up is active,
+// awarding the configured ghost bonus to that snake's player for each one
+func eatGhosts(state *State) {
+	if state.powerUpTimer == 0 {
+		return
+	}
+	for s := range state.snakes {
+		head := state.snakes[s].getHead()
+		for i := len(state.level.ghosts) - 1; i >= 0; i-- {
+			if state.level.ghosts[i].position == head {
+				state.level.ghosts = state.level.ghosts.removeAt(i)
+				state.addScore(&state.snakes[s], state.config.ghostBonus)
+			}
+		}
+	}
 }
 
 type Level struct {
-	id       int
-	walls    Slice[Slice[bool]]
-	foods    Slice[Vec2]
+	id int
+	// name is shown alongside the level's number, if it has one
+	name  string
+	walls Slice[Slice[bool]]
+	foods Slice[Food]
+	// foodValues holds the points for foods worth more than 1
+	foodValues map[Vec2]int
+	// digPickups are eaten for dig charges
+	digPickups Slice[Vec2]
+	// slowPickups are eaten for a spell of slow motion
+	slowPickups Slice[Vec2]
+	// portals maps each portal cell to its partner, in both directions
+	portals map[Vec2]Vec2
+	// arrows maps each arrow tile to the direction it pushes the snake
+	arrows map[Vec2]Vec2
+	// gates are walls that open and close on a timer. their cells in walls
+	// hold whether they are closed right now.
+	gates    Slice[Gate]
+	ghosts   Slice[Ghost]
 	entrance Vec2
 	exit     Vec2
 	width    int
 	height   int
+	// timeLimit is the number of seconds allowed to finish the level, or 0 for
+	// no limit
+	timeLimit int
+	// par is the number of seconds to beat to finish the level under par, or
+	// 0 if it has none
+	par int
+	// solidEdges stops the level wrapping around, so going off an edge is a
+	// crash instead
+	solidEdges bool
+	// requireAllFood keeps the exit locked until every food that scores has
+	// been eaten
+	requireAllFood bool
+	// background replaces the palette's background color while the level is
+	// played, unless it's left transparent
+	background color.RGBA
+}
+
+// title returns how the level is named on screen: its number, followed by its
+// name if it has one
+func (level *Level) title() string {
+	title := "level " + strconv.Itoa(level.id)
+	if level.name != "" {
+		title += ": " + level.name
+	}
+	return title
 }
 
 // NewLevel creates a new instance of Level from the given id by loading the
-// associated text file from the assets folder
-func NewLevel(id int) Level {
-	level := Level{id: id}
-	filename := fmt.Sprintf("assets/level-%d.txt", id)
-	content, err := assets.ReadFile(filename)
+// associated text file from the root of levels, which is either the embedded
+// assets folder or a directory given with -levels. an error wrapping
+// [fs.ErrNotExist] is returned when there is no level with that id.
+func NewLevel(levels fs.FS, id int) (Level, error) {
+	filename := fmt.Sprintf("level-%d.txt", id)
+	content, err := fs.ReadFile(levels, filename)
 	if err != nil {
-		log.Fatal(err)
+		return Level{}, fmt.Errorf("loading level %d: %w", id, err)
+	}
+	return parseLevel(id, string(content))
+}
+
+// loadLevel returns the level with the given id, either from the level files or
+// generated from the seed when the config asks for generated levels
+func loadLevel(id int, config Config) (Level, error) {
+	if config.generate {
+		return generatedLevel(id, config), nil
+	}
+	level, err := NewLevel(config.levels, id)
+	if err != nil {
+		return Level{}, err
+	}
+	level.setGhostCount(config.ghostCount(len(level.ghosts)))
+	return level, nil
+}
+
+// generatedLevel returns the generated level with the given id. each level
+// draws from its own stream so restarting a level rebuilds the same maze no
+// matter how much randomness was used before it.
+func generatedLevel(id int, config Config) Level {
+	rng := rand.New(rand.NewSource(int64(config.seed + id)))
+	level := GenerateLevel(GENERATED_WIDTH, GENERATED_HEIGHT, rng)
+	level.id = id
+	if config.mode == ModeDaily {
+		level.name = "daily " + dailyDate(config.seed)
+	}
+	level.setGhostCount(config.ghostCount(len(level.ghosts)))
+	return level
+}
+
+// arrowDirections maps the arrow tile characters to the direction they push
+var arrowDirections = map[rune]Vec2{
+	'^': {x: 0, y: -1},
+	'v': {x: 0, y: 1},
+	'<': {x: -1, y: 0},
+	'>': {x: 1, y: 0},
+}
+
+// parseLevel builds a Level from the text representation used by the level
+// files, returning a descriptive error if the level is invalid
+func parseLevel(id int, levelString string) (Level, error) {
+	level := Level{id: id}
+	lines := NewSlice[string]()
+	gatePeriod := GATE_PERIOD
+
+	// lines starting with ';' hold metadata such as ";time=60", and any with a
+	// key that isn't recognized are comments. they and blank lines are left
+	// out of the grid.
+	for _, line := range strings.Split(levelString, "\n") {
+		line = strings.TrimRight(line, "\r")
+		if line == "" {
+			continue
+		}
+		if !strings.HasPrefix(line, ";") {
+			lines = append(lines, line)
+			continue
+		}
+
+		key, value, _ := strings.Cut(line[1:], "=")
+		value = strings.TrimSpace(value)
+		switch strings.TrimSpace(key) {
+		case "name":
+			level.name = value
+		case "time":
+			seconds, err := strconv.Atoi(value)
+			if err != nil || seconds < 0 {
+				return Level{}, fmt.Errorf("invalid level %d: bad time limit %q", id, value)
+			}
+			level.timeLimit = seconds
+		case "par":
+			seconds, err := strconv.Atoi(value)
+			if err != nil || seconds < 0 {
+				return Level{}, fmt.Errorf("invalid level %d: bad par time %q", id, value)
+			}
+			level.par = seconds
+		case "wrap":
+			wrap, err := strconv.ParseBool(value)
+			if err != nil {
+				return Level{}, fmt.Errorf("invalid level %d: bad wrap %q", id, value)
+			}
+			level.solidEdges = !wrap
+		case "allfood":
+			requireAllFood, err := strconv.ParseBool(value)
+			if err != nil {
+				return Level{}, fmt.Errorf("invalid level %d: bad allfood %q", id, value)
+			}
+			level.requireAllFood = requireAllFood
+		case "gate":
+			seconds, err := strconv.Atoi(value)
+			if err != nil || seconds <= 0 {
+				return Level{}, fmt.Errorf("invalid level %d: bad gate period %q", id, value)
+			}
+			gatePeriod = seconds * FPS
+		case "bg":
+			background, err := parseHexColor(value)
+			if err != nil {
+				return Level{}, fmt.Errorf("invalid level %d: %w", id, err)
+			}
+			level.background = background
+		}
 	}
-	levelString := string(content)
 
-	lines := strings.Split(strings.TrimSpace(levelString), "\n")
+	if len(lines) == 0 || len(lines[0]) == 0 {
+		return Level{}, fmt.Errorf("invalid level %d: level is empty", id)
+	}
 	level.height = len(lines)
 	level.width = len(lines[0])
+	for y, line := range lines {
+		if len(line) != level.width {
+			return Level{}, fmt.Errorf("invalid level %d: row %d has length %d, expected %d", id, y+1, len(line), level.width)
+		}
+	}
 	level.walls = make(Slice[Slice[bool]], level.height)
-	level.foods = Slice[Vec2]{}
+	level.foods = Slice[Food]{}
+	level.foodValues = map[Vec2]int{}
+	level.digPickups = Slice[Vec2]{}
+	level.slowPickups = Slice[Vec2]{}
+	level.portals = map[Vec2]Vec2{}
+	level.arrows = map[Vec2]Vec2{}
+	level.gates = Slice[Gate]{}
+	level.ghosts = Slice[Ghost]{}
+	// portalCells collects the cells of each portal letter to pair them up
+	portalCells := map[rune]Slice[Vec2]{}
+	// the entrance and exit can be in any cell, including {0 0}, so whether
+	// they were found is tracked separately
+	hasEntrance, hasExit := false, false
 
 	for y, line := range lines {
 		level.walls[y] = make(Slice[bool], level.width)
@@ -240,172 +980,1297 @@ func NewLevel(id int) Level {
 			case '#':
 				level.walls[y][x] = true
 			case 'F':
-				level.foods = append(level.foods, Vec2{x: x, y: y})
+				level.foods = append(level.foods, Food{position: Vec2{x: x, y: y}, kind: FoodNormal})
+			case '1', '2', '3', '4', '5', '6', '7', '8', '9':
+				level.foods = append(level.foods, Food{position: Vec2{x: x, y: y}, kind: FoodNormal})
+				level.foodValues[Vec2{x: x, y: y}] = int(char - '0')
+			case 'P', '-', '$':
+				level.foods = append(level.foods, Food{position: Vec2{x: x, y: y}, kind: foodKinds[char]})
+			case 'D':
+				level.digPickups = append(level.digPickups, Vec2{x: x, y: y})
+			case 'Z':
+				level.slowPickups = append(level.slowPickups, Vec2{x: x, y: y})
 			case 'S':
 				level.entrance = Vec2{x: x, y: y}
+				hasEntrance = true
 			case 'E':
 				level.exit = Vec2{x: x, y: y}
+				hasExit = true
+			case 'G':
+				level.ghosts = append(level.ghosts, NewGhost(Vec2{x: x, y: y}))
+			case 'a', 'b', 'c', 'd', 'e', 'f', 'g', 'h', 'i', 'j':
+				portalCells[char] = append(portalCells[char], Vec2{x: x, y: y})
+			case '^', 'v', '<', '>':
+				level.arrows[Vec2{x: x, y: y}] = arrowDirections[char]
+			case 'T', 't':
+				// gates are left open here so solvability is checked with
+				// them passable, and are first closed by updateGates
+				level.gates = append(level.gates, NewGate(Vec2{x: x, y: y}, gatePeriod, char == 't'))
 			}
 		}
 	}
 
-	if level.entrance == (Vec2{}) || level.exit == (Vec2{}) || len(level.foods) == 0 {
-		log.Fatal("Invalid level: missing snake start, exit, or food")
+	for letter, cells := range portalCells {
+		if len(cells) != 2 {
+			return Level{}, fmt.Errorf("invalid level %d: portal '%c' has %d cells, expected 2", id, letter, len(cells))
+		}
+		level.portals[cells[0]] = cells[1]
+		level.portals[cells[1]] = cells[0]
 	}
 
-	return level
-}
-
-var startBlinkCounter int = 0
+	if !hasEntrance {
+		return Level{}, fmt.Errorf("invalid level %d: missing snake start 'S'", id)
+	}
+	if !hasExit {
+		return Level{}, fmt.Errorf("invalid level %d: missing exit 'E'", id)
+	}
+	if len(level.scoringFoods()) == 0 {
+		return Level{}, fmt.Errorf("invalid level %d: missing food 'F'", id)
+	}
+	if !level.IsSolvable() {
+		return Level{}, fmt.Errorf("invalid level %d: exit or food can't be reached from the snake start", id)
+	}
 
-type State struct {
-	snake        Snake
-	level        Level
-	status       Status
-	score        int
-	viewportX    int
-	powerUpTimer int
+	return level, nil
 }
 
-func handleInput() {
-	if ebiten.IsKeyPressed(ebiten.KeyArrowLeft) && state.snake.prevDirection.x == 0 {
-		state.snake.direction = Vec2{x: -1, y: 0}
+// String formats the level in the text format read by parseLevel
+func (level *Level) String() string {
+	var builder strings.Builder
+	if level.name != "" {
+		fmt.Fprintf(&builder, ";name=%s\n", level.name)
 	}
-	if ebiten.IsKeyPressed(ebiten.KeyArrowRight) && state.snake.prevDirection.x == 0 {
-		state.snake.direction = Vec2{x: 1, y: 0}
+	if level.timeLimit > 0 {
+		fmt.Fprintf(&builder, ";time=%d\n", level.timeLimit)
 	}
-	if ebiten.IsKeyPressed(ebiten.KeyArrowUp) && state.snake.prevDirection.y == 0 {
-		state.snake.direction = Vec2{x: 0, y: -1}
+	if level.par > 0 {
+		fmt.Fprintf(&builder, ";par=%d\n", level.par)
 	}
-	if ebiten.IsKeyPressed(ebiten.KeyArrowDown) && state.snake.prevDirection.y == 0 {
-		state.snake.direction = Vec2{x: 0, y: 1}
+	if level.solidEdges {
+		builder.WriteString(";wrap=false\n")
 	}
-}
-
-// updateViewport adjusts the viewport x to follow the snake when it is past a
-// certain the center of the viewport by a certain amount
-func updateViewport() {
-	head := state.snake.getHead()
-	if head.x-state.viewportX > VIEWPORT_WIDTH*3/4 {
-		state.viewportX = head.x - VIEWPORT_WIDTH*3/4
-	} else if head.x-state.viewportX < VIEWPORT_WIDTH/4 {
-		state.viewportX = head.x - VIEWPORT_WIDTH/4
+	if level.requireAllFood {
+		builder.WriteString(";allfood=true\n")
+	}
+	if level.background.A > 0 {
+		fmt.Fprintf(&builder, ";bg=#%02x%02x%02x\n", level.background.R, level.background.G, level.background.B)
+	}
+	if len(level.gates) > 0 && level.gates[0].period != GATE_PERIOD {
+		fmt.Fprintf(&builder, ";gate=%d\n", level.gates[0].period/FPS)
+	}
+	gates := map[Vec2]Gate{}
+	for _, gate := range level.gates {
+		gates[gate.position] = gate
 	}
 
-	if state.viewportX < 0 {
-		state.viewportX = 0
-	} else if state.viewportX > state.level.width-VIEWPORT_WIDTH {
-		state.viewportX = state.level.width - VIEWPORT_WIDTH
+	foods := map[Vec2]FoodKind{}
+	for _, food := range level.foods {
+		foods[food.position] = food.kind
+	}
+	pickups := map[Vec2]bool{}
+	for _, pickup := range level.digPickups {
+		pickups[pickup] = true
+	}
+	slowPickups := map[Vec2]bool{}
+	for _, pickup := range level.slowPickups {
+		slowPickups[pickup] = true
+	}
+	// portal pairs are lettered from 'a' in the order they are first met
+	portalLetters := map[Vec2]byte{}
+	nextLetter := byte('a')
+	isPortal := func(position Vec2) bool {
+		_, ok := level.portals[position]
+		return ok
 	}
+	for y := 0; y < level.height; y++ {
+		for x := 0; x < level.width; x++ {
+			position := Vec2{x: x, y: y}
+			gate, isGate := gates[position]
+			kind, isFood := foods[position]
+			switch {
+			case isGate && gate.phase == 0:
+				builder.WriteByte('T')
+			case isGate:
+				builder.WriteByte('t')
+			case level.walls[y][x]:
+				builder.WriteByte('#')
+			case position == level.entrance:
+				builder.WriteByte('S')
+			case position == level.exit:
+				builder.WriteByte('E')
+			case level.ghostAt(position):
+				builder.WriteByte('G')
+			case isFood && kind != FoodNormal:
+				for char, foodKind := range foodKinds {
+					if foodKind == kind {
+						builder.WriteRune(char)
+					}
+				}
+			case pickups[position]:
+				builder.WriteByte('D')
+			case slowPickups[position]:
+				builder.WriteByte('Z')
+			case level.arrows[position] != (Vec2{}):
+				for char, direction := range arrowDirections {
+					if direction == level.arrows[position] {
+						builder.WriteRune(char)
+					}
+				}
+			case isPortal(position):
+				if _, ok := portalLetters[position]; !ok {
+					portalLetters[position] = nextLetter
+					portalLetters[level.portals[position]] = nextLetter
+					nextLetter++
+				}
+				builder.WriteByte(portalLetters[position])
+			case isFood && level.foodValue(position) > 1:
+				builder.WriteString(strconv.Itoa(level.foodValue(position)))
+			case isFood:
+				builder.WriteByte('F')
+			default:
+				builder.WriteByte(' ')
+			}
+		}
+		builder.WriteByte('\n')
+	}
+	return builder.String()
 }
 
-func main() {
-	ebiten.SetWindowSize(SCREEN_WIDTH, SCREEN_HEIGHT)
-	ebiten.SetWindowTitle(TITLE)
-
-	game := &Game{}
-	if err := ebiten.RunGame(game); err != nil {
-		log.Fatal(err)
+// wrap returns the given position wrapped around the level boundaries, matching
+// the toroidal movement in createHead
+func (level *Level) wrap(position Vec2) Vec2 {
+	return Vec2{
+		x: (position.x + level.width) % level.width,
+		y: (position.y + level.height) % level.height,
 	}
 }
 
-// Empty struct to satisfy ebitengine interface
-type Game struct{}
-
-// satisfies the main layout method from the [ebiten.Game] interface
-//
-// [ebiten.Game]: https://pkg.go.dev/github.com/hajimehoshi/ebiten/v2#Game
-func (*Game) Layout(outsideWidth, outsideHeight int) (screenWidth, screenHeight int) {
-	return SCREEN_WIDTH, SCREEN_HEIGHT
+// wrappedDelta returns the shortest vector from a to b, going off one edge of
+// the level and back on at the opposite one when that's shorter, the same way
+// the snake wraps. levels with solid edges don't wrap, so it's just b - a on
+// those. a cell exactly half way around is reached without wrapping.
+func (level *Level) wrappedDelta(a Vec2, b Vec2) Vec2 {
+	d := b.sub(a)
+	if level.solidEdges {
+		return d
+	}
+	if 2*d.x > level.width {
+		d.x -= level.width
+	} else if 2*d.x < -level.width {
+		d.x += level.width
+	}
+	if 2*d.y > level.height {
+		d.y -= level.height
+	} else if 2*d.y < -level.height {
+		d.y += level.height
+	}
+	return d
 }
 
-// satisfies the main drawing method from [ebiten.Game]
-//
-// [ebiten.Game]: https://pkg.go.dev/github.com/hajimehoshi/ebiten/v2#Game
-func (*Game) Draw(screen *ebiten.Image) {
-	screen.Fill(color.RGBA{0, 0, 0, 255})
+// wrappedDistance returns the number of steps between two cells, ignoring
+// walls, taking the shorter way around wherever the level wraps
+func (level *Level) wrappedDistance(a Vec2, b Vec2) int {
+	d := level.wrappedDelta(a, b)
+	return abs(d.x) + abs(d.y)
+}
 
-	switch state.status {
-	case StatusStarted:
-		drawStartScreen(screen)
-	case StatusPlaying, StatusLost, StatusWon:
-		drawLevel(screen)
-		drawSnake(screen)
-		drawHUD(screen)
+// crossesEdge reports whether moving one cell from position in the given
+// direction would go off a solid edge of the level
+func (level *Level) crossesEdge(position Vec2, direction Vec2) bool {
+	if !level.solidEdges {
+		return false
 	}
+	next := position.add(direction)
+	return next.x < 0 || next.x >= level.width || next.y < 0 || next.y >= level.height
 }
 
-func drawStartScreen(screen *ebiten.Image) {
-	titleWidth := float64(len(TITLE)) * font.regular.Size
-	titleHeight := font.regular.Size
-
-	op := &text.DrawOptions{}
-	op.GeoM.Translate((float64(SCREEN_WIDTH)-titleWidth)/2, float64(SCREEN_HEIGHT)/2-titleHeight/2-30)
-	text.Draw(screen, TITLE, &font.regular, op)
-
-	if startBlinkCounter < 30 {
-		startText := "press SPACE to start"
-		startWidth := float64(len(startText)) * font.regular.Size
-
-		op.GeoM.Reset()
-		op.GeoM.Translate((float64(SCREEN_WIDTH)-startWidth)/2, float64(SCREEN_HEIGHT)/2+30)
-		text.Draw(screen, startText, &font.regular, op)
+// step returns the cell reached by moving one cell from position in the given
+// direction, wrapping around the level boundaries. stepping onto a portal
+// comes out of its partner instead. a step off a solid edge stays where it
+// is, which searches treat as already visited.
+func (level *Level) step(position Vec2, direction Vec2) Vec2 {
+	if level.crossesEdge(position, direction) {
+		return position
 	}
+	next := level.wrap(position.add(direction))
+	if partner, ok := level.portals[next]; ok {
+		return partner
+	}
+	return next
 }
 
-func drawLevel(screen *ebiten.Image) {
-	for y := 0; y < state.level.height; y++ {
-		for x := 0; x < VIEWPORT_WIDTH; x++ {
-			worldX := x + state.viewportX
-			if worldX < state.level.width && state.level.walls[y][worldX] {
-				vector.DrawFilledRect(screen, float32(x*GRID_SIZE), float32(y*GRID_SIZE), GRID_SIZE-1, GRID_SIZE-1, color.RGBA{100, 100, 100, 255}, true)
+// reachableFrom returns the set of non-wall cells that can be reached from
+// start with a breadth-first search, following the same wrapping, portals, and
+// arrow tiles as the snake
+func (level *Level) reachableFrom(start Vec2) map[Vec2]bool {
+	reached := map[Vec2]bool{start: true}
+	queue := NewSlice(start)
+	for len(queue) > 0 {
+		current := queue[0]
+		queue = queue[1:]
+		for _, direction := range directions {
+			if forced, ok := level.arrows[current]; ok && direction != forced {
+				continue
+			}
+			next := level.step(current, direction)
+			if level.walls[next.y][next.x] || reached[next] {
+				continue
 			}
+			reached[next] = true
+			queue = append(queue, next)
 		}
 	}
+	return reached
+}
 
-	// draw foods
-	for _, food := range state.level.foods {
-		if food.x >= state.viewportX && food.x < state.viewportX+VIEWPORT_WIDTH {
-			vector.DrawFilledRect(screen, float32((food.x-state.viewportX)*GRID_SIZE), float32(food.y*GRID_SIZE), GRID_SIZE-1, GRID_SIZE-1, color.RGBA{255, 0, 0, 255}, true)
+// IsSolvable reports whether the exit and every food can be reached from the
+// entrance, which catches levels walled off by a typo in the level file
+func (level *Level) IsSolvable() bool {
+	reached := level.reachableFrom(level.entrance)
+	if !reached[level.exit] {
+		return false
+	}
+	for _, food := range level.foods {
+		if !reached[food.position] {
+			return false
 		}
 	}
+	return true
+}
+
+// nextStepTowards returns the cell one step from from that lies on a shortest
+// path to target, found with a breadth-first search over the non-wall cells
+// that follows portals. from is returned unchanged when the target is
+// unreachable or already reached.
+func (level *Level) nextStepTowards(from Vec2, target Vec2) Vec2 {
+	if from == target {
+		return from
+	}
 
-	// draw exit
-	if state.level.exit.x >= state.viewportX && state.level.exit.x < state.viewportX+VIEWPORT_WIDTH {
-		c := color.RGBA{0, 0, 0, 255} // black
-		vector.DrawFilledRect(screen, float32((state.level.exit.x-state.viewportX)*GRID_SIZE), float32(state.level.exit.y*GRID_SIZE), GRID_SIZE-1, GRID_SIZE-1, c, true)
+	// firstSteps maps each visited cell to the first step taken from the start
+	// on the way to reach it
+	firstSteps := map[Vec2]Vec2{from: from}
+	queue := NewSlice(from)
+	for len(queue) > 0 {
+		current := queue[0]
+		queue = queue[1:]
+		for _, direction := range directions {
+			next := level.step(current, direction)
+			if level.walls[next.y][next.x] {
+				continue
+			}
+			if _, visited := firstSteps[next]; visited {
+				continue
+			}
+			step := firstSteps[current]
+			if current == from {
+				step = next
+			}
+			if next == target {
+				return step
+			}
+			firstSteps[next] = step
+			queue = append(queue, next)
+		}
 	}
+	return from
 }
 
-func drawSnake(screen *ebiten.Image) {
-	head := state.snake.getHead()
-	blue := color.RGBA{0, 0, 255, 255}
-	green := color.RGBA{0, 255, 0, 255}
-	for _, p := range state.snake.body {
-		if p.x >= state.viewportX && p.x < state.viewportX+VIEWPORT_WIDTH {
-			headColor := green
-			bodyColor := dimColor(green, 0.8)
-			if state.powerUpTimer > 0 {
-				// flash between green and blue
-				if state.powerUpTimer%10 < 5 {
-					headColor = blue
-					bodyColor = dimColor(blue, 0.8)
-				} else {
-					headColor = green
-					bodyColor = dimColor(green, 0.8)
-				}
+// wallNeighbors returns a bitmask of WALL_UP, WALL_DOWN, WALL_LEFT and
+// WALL_RIGHT for the walls next to the given position. neighbors wrap around
+// the level edges, so walls on either side of the seam join up.
+func (level *Level) wallNeighbors(position Vec2) int {
+	isWall := func(x, y int) bool {
+		neighbor := level.wrap(Vec2{x: x, y: y})
+		return level.walls[neighbor.y][neighbor.x]
+	}
+
+	mask := 0
+	if isWall(position.x, position.y-1) {
+		mask |= WALL_UP
+	}
+	if isWall(position.x, position.y+1) {
+		mask |= WALL_DOWN
+	}
+	if isWall(position.x-1, position.y) {
+		mask |= WALL_LEFT
+	}
+	if isWall(position.x+1, position.y) {
+		mask |= WALL_RIGHT
+	}
+	return mask
+}
+
+// exitLocked reports whether the exit does nothing yet, because the level
+// requires all of its food to be eaten first and some is left
+func (level *Level) exitLocked() bool {
+	return level.requireAllFood && len(level.scoringFoods()) > 0
+}
+
+// foodValue returns how many points the food at the given position is worth.
+// plain 'F' food is worth 1.
+func (level *Level) foodValue(position Vec2) int {
+	if value, ok := level.foodValues[position]; ok {
+		return value
+	}
+	return 1
+}
+
+// rivalEntrance returns where the second player's snake starts in versus
+// mode: the first open cell next to the entrance, or the entrance itself if it
+// is boxed in
+func (level *Level) rivalEntrance() Vec2 {
+	for _, direction := range directions {
+		if level.crossesEdge(level.entrance, direction) {
+			continue
+		}
+		next := level.wrap(level.entrance.add(direction))
+		if !level.walls[next.y][next.x] && next != level.exit {
+			return next
+		}
+	}
+	return level.entrance
+}
+
+// ghostAt reports whether any ghost occupies the given position
+func (level *Level) ghostAt(position Vec2) bool {
+	for _, ghost := range level.ghosts {
+		if ghost.position == position {
+			return true
+		}
+	}
+	return false
+}
+
+// completeLevel ends the level once the snake reaches the exit. the game is won
+// if it was the last level or the daily challenge, otherwise it waits on
+// StatusLevelComplete until advanceLevel is called to load the next one.
+func completeLevel(state *State) {
+	if state.level.par > 0 && state.frame-state.levelStartFrame < state.level.par*FPS {
+		state.earn(AchievementUnderPar)
+	}
+	if !state.hasNextLevel() {
+		state.status = StatusWon
+		state.earnWin()
+		state.playSound(SoundWin)
+		return
+	}
+	state.status = StatusLevelComplete
+}
+
+// hasNextLevel reports whether there's a level after the current one. the
+// daily challenge is a single maze, and other generated games never run out.
+func (state *State) hasNextLevel() bool {
+	if state.config.mode == ModeDaily {
+		return false
+	}
+	return state.config.generate || len(state.upcoming) > 0
+}
+
+// advanceLevel starts the level following the current one and resets the snake
+// to its entrance, carrying the score over. when there are no more levels the
+// game is won.
+func advanceLevel(state *State) {
+	if !state.hasNextLevel() {
+		state.status = StatusWon
+		state.playSound(SoundWin)
+		return
+	}
+	var level Level
+	if state.config.generate {
+		level = generatedLevel(state.level.id+1, state.config)
+	} else {
+		level = state.upcoming[0]
+		state.upcoming = state.upcoming[1:]
+	}
+
+	state.level = level
+	state.timeRemaining = level.timeLimit * FPS
+	respawnSnake(state)
+	state.viewportX = 0
+	state.viewportY = 0
+	state.powerUpTimer = 0
+	state.slowMotionTimer = 0
+	state.bonusFrames = 0
+	state.bonusTimer = BONUS_INTERVAL
+	state.suddenDeathTimer = state.config.suddenDeathTime
+	state.suddenDeathPending = false
+	state.levelStartScore = state.score
+	state.levelStartFrame = state.frame
+	state.run = nil
+	state.bestRun = nil
+	state.history = nil
+	state.bannerFrames = LEVEL_BANNER_FRAMES
+	state.status = StatusPlaying
+}
+
+// availableLevels lists the ids of the level files in levels, sorted in
+// ascending order
+func availableLevels(levels fs.FS) (Slice[int], error) {
+	filenames, err := fs.Glob(levels, "level-*.txt")
+	if err != nil {
+		return nil, err
+	}
+
+	ids := NewSlice[int]()
+	for _, filename := range filenames {
+		var id int
+		if _, err := fmt.Sscanf(filename, "level-%d.txt", &id); err != nil {
+			continue
+		}
+		ids = append(ids, id)
+	}
+	sort.Ints(ids)
+	return ids, nil
+}
+
+type State struct {
+	// snakes holds player one's snake, followed by player two's in versus mode
+	snakes Slice[Snake]
+	level  Level
+	// upcoming holds the levels after the current one, in order. generated
+	// games make each level as it's reached instead, so it stays empty.
+	upcoming Slice[Level]
+	status   Status
+	score    int
+	// levelStartScore is the score the current level began with, and
+	// levelStartFrame the frame it began on
+	levelStartScore int
+	levelStartFrame int
+	// digCharges is the number of walls the player can still dig through
+	digCharges   int
+	viewportX    int
+	viewportY    int
+	powerUpTimer int
+	// slowMotionTimer counts down the frames left of slow motion
+	slowMotionTimer int
+	lives           int
+	config          Config
+	// rng is seeded from config.seed and used for every random decision made
+	// during play, so a game with the same seed and input plays out the same
+	rng *rand.Rand
+	// layout is kept in sync with the window by Game.Layout
+	layout Layout
+	// timeRemaining counts down the frames left on levels with a time limit
+	timeRemaining int
+	// frame counts the frames played so far, and lastEatFrame is the frame
+	// food was last eaten on to time the combo multiplier
+	frame        int
+	lastEatFrame int
+	combo        int
+	// winner is the index of the snake that won a versus game
+	winner int
+	// perfect is set when the game was won by filling the level
+	perfect bool
+	// practice makes a collision respawn the snake without costing a life,
+	// and stops anything being scored
+	practice bool
+	// noclip lets snakes pass through walls and each other, for flying around
+	// a level to check its layout. it's toggled with ctrl+n in -debug games.
+	noclip bool
+	// bonus is where the bonus fruit is while bonusFrames counts down the
+	// frames left before it disappears, and bonusTimer counts down the frames
+	// until the next one appears
+	bonus       Vec2
+	bonusFrames int
+	bonusTimer  int
+	// suddenDeathTimer counts down the frames until suddenDeathCell is walled
+	// in, and suddenDeathPending is set once that cell has been picked
+	suddenDeathTimer   int
+	suddenDeathCell    Vec2
+	suddenDeathPending bool
+	// bannerFrames counts down the frames left to show the level's title
+	bannerFrames int
+	// dyingFrames counts down the frames left of the death animation
+	dyingFrames int
+	// endFrames counts the frames the end screen has been shown for
+	endFrames int
+	// foodEaten and deaths count what's happened over the whole game, and
+	// earned holds the achievements it has met that Game hasn't collected yet
+	foodEaten int
+	deaths    int
+	earned    Slice[Achievement]
+	// pendingSounds holds the sound effects queued by playSound since Game
+	// last played them
+	pendingSounds Slice[Sound]
+	// run records player one's path through the current level, and bestRun is
+	// the fastest earlier one, drawn for the snake to race
+	run     Slice[Vec2]
+	bestRun Slice[Vec2]
+	// history holds copies of the state from before player one's last few
+	// moves, for rewinding to. rewindCharges is how many rewinds are left,
+	// and rewindHeld is set while the rewind key is down.
+	history       Slice[State]
+	rewindCharges int
+	rewindHeld    bool
+	// popups are the points shown rising from food that was just eaten
+	popups Slice[Popup]
+	// wallLayer and minimapCache cache the level's walls. they're rebuilt
+	// when they no longer match the level, and cleared by invalidateWalls
+	// when a wall is added or removed.
+	wallLayer    *WallLayer
+	minimapCache *Minimap
+}
+
+// wasdKeys holds the keys player two steers with in versus mode, in the same
+// order as directions
+var wasdKeys = [4]ebiten.Key{ebiten.KeyW, ebiten.KeyS, ebiten.KeyA, ebiten.KeyD}
+
+// readInput returns the directions held down for each snake, as a bitmask with
+// bit i set when directions[i] is held, DIG_INPUT and REWIND_INPUT set while
+// player one holds the dig and rewind keys, and NOCLIP_INPUT set on the frame
+// ctrl+n is pressed in a -debug game. the bound direction keys,
+// WASD, and gamepads all steer the snake, except in versus mode where player
+// one keeps the bound keys and gamepad while player two steers with WASD.
+func readInput(state *State) Slice[int] {
+	input := make(Slice[int], len(state.snakes))
+	boundKeys := state.config.keys.directionKeys()
+	for i, direction := range directions {
+		bound := ebiten.IsKeyPressed(boundKeys[i]) || isGamepadDirectionPressed(direction)
+		wasd := ebiten.IsKeyPressed(wasdKeys[i])
+		if state.config.mode == ModeVersus {
+			if bound {
+				input[0] |= 1 << i
+			}
+			if wasd {
+				input[1] |= 1 << i
+			}
+		} else if bound || wasd {
+			input[0] |= 1 << i
+		}
+	}
+	if ebiten.IsKeyPressed(state.config.keys.key(ActionDig)) {
+		input[0] |= DIG_INPUT
+	}
+	if ebiten.IsKeyPressed(state.config.keys.key(ActionRewind)) {
+		input[0] |= REWIND_INPUT
+	}
+	if state.config.debug && ebiten.IsKeyPressed(ebiten.KeyControl) && inpututil.IsKeyJustPressed(ebiten.KeyN) {
+		input[0] |= NOCLIP_INPUT
+	}
+	return input
+}
+
+// NOCLIP_INPUT is the bit set in an input mask, past REWIND_INPUT, to toggle
+// noclip. it's part of the input rather than set on State directly so that
+// recordings replay it on the same frame.
+const NOCLIP_INPUT = REWIND_INPUT << 1
+
+// the bits of an input mask for the two directions along each axis, in the
+// order of directions
+const (
+	INPUT_VERTICAL   = 1<<0 | 1<<1
+	INPUT_HORIZONTAL = 1<<2 | 1<<3
+)
+
+// cancelOpposites clears both directions of any axis where both are held, so
+// holding left and right together does nothing rather than favoring one
+func cancelOpposites(mask int) int {
+	for _, axis := range [2]int{INPUT_VERTICAL, INPUT_HORIZONTAL} {
+		if mask&axis == axis {
+			mask &^= axis
+		}
+	}
+	return mask
+}
+
+// handleInput queues turns for each snake from its input mask, sets whether it
+// is digging, and toggles noclip for player one's NOCLIP_INPUT. opposite
+// directions held together cancel out. of the rest, a vertical turn is queued
+// before a horizontal one, so holding up and left while heading right turns up
+// and then left. every held direction shares the same guard against reversing.
+func handleInput(state *State, input Slice[int]) {
+	for s := range state.snakes {
+		mask := 0
+		if s < len(input) {
+			mask = cancelOpposites(input[s])
+		}
+		for i, direction := range directions {
+			if mask&(1<<i) != 0 {
+				state.snakes[s].queueDirection(direction)
 			}
+		}
+		state.snakes[s].digging = mask&DIG_INPUT != 0
+		if s == 0 && mask&NOCLIP_INPUT != 0 {
+			state.noclip = !state.noclip
+		}
+	}
+}
+
+// updateViewport adjusts the viewport x and y to follow the snake when it is
+// past the center of the viewport by a certain amount. in versus mode it
+// follows the point between both snakes' heads.
+func updateViewport(state *State) {
+	head := state.snakes[0].getHead()
+	if state.config.mode == ModeVersus {
+		rival := state.snakes[1].getHead()
+		head = Vec2{x: (head.x + rival.x) / 2, y: (head.y + rival.y) / 2}
+	}
+	viewportWidth := state.layout.viewportWidth()
+	viewportHeight := state.layout.viewportHeight()
+
+	// a level that fits inside the viewport along an axis is shown whole,
+	// centered by viewportMargin, so there is nothing to follow
+	follow := followWrapped
+	if state.level.solidEdges {
+		follow = followClamped
+	}
+	if state.level.width >= viewportWidth {
+		state.viewportX = follow(head.x, state.viewportX, viewportWidth, state.level.width)
+	} else {
+		state.viewportX = 0
+	}
+	if state.level.height >= viewportHeight {
+		state.viewportY = follow(head.y, state.viewportY, viewportHeight, state.level.height)
+	} else {
+		state.viewportY = 0
+	}
+}
+
+// viewportMargin returns the number of empty cells drawn before the level on
+// each axis where it is smaller than the viewport, which centers it on screen
+func viewportMargin(state *State) Vec2 {
+	margin := Vec2{}
+	if state.level.width < state.layout.viewportWidth() {
+		margin.x = (state.layout.viewportWidth() - state.level.width) / 2
+	}
+	if state.level.height < state.layout.viewportHeight() {
+		margin.y = (state.layout.viewportHeight() - state.level.height) / 2
+	}
+	return margin
+}
+
+// followWrapped scrolls one axis of the viewport so the head stays in its
+// middle half, on a level that wraps around after levelSize cells. the head
+// is measured from the viewport the short way round, so the view keeps moving
+// smoothly when the snake crosses the seam.
+func followWrapped(head int, viewport int, viewportSize int, levelSize int) int {
+	offset := mod(head-(viewport+viewportSize/2), levelSize)
+	if offset >= levelSize/2 {
+		offset -= levelSize
+	}
+	offset += viewportSize / 2
 
-			if p == head {
-				if state.status == StatusLost {
-					headColor = color.RGBA{255, 165, 0, 120} // orange
+	if offset > viewportSize*3/4 {
+		viewport = head - viewportSize*3/4
+	} else if offset < viewportSize/4 {
+		viewport = head - viewportSize/4
+	}
+	return mod(viewport, levelSize)
+}
+
+// followClamped scrolls one axis of the viewport like followWrapped, on a level
+// with solid edges. the view stops at the edges instead of showing the far
+// side of the level past them.
+func followClamped(head int, viewport int, viewportSize int, levelSize int) int {
+	if head-viewport > viewportSize*3/4 {
+		viewport = head - viewportSize*3/4
+	} else if head-viewport < viewportSize/4 {
+		viewport = head - viewportSize/4
+	}
+	if viewport > levelSize-viewportSize {
+		viewport = levelSize - viewportSize
+	}
+	if viewport < 0 {
+		viewport = 0
+	}
+	return viewport
+}
+
+// mod returns a modulo n, always in the range [0, n)
+func mod(a int, n int) int {
+	return (a%n + n) % n
+}
+
+// abs returns the absolute value of n
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
+
+func main() {
+	config := DefaultConfig()
+	flag.IntVar(&config.screenWidth, "width", SCREEN_WIDTH, "starting window width in pixels")
+	flag.IntVar(&config.screenHeight, "height", SCREEN_HEIGHT, "starting window height in pixels")
+	flag.IntVar(&config.gridSize, "grid", config.gridSize, "size of a level cell in pixels at the starting window size")
+	flag.IntVar(&config.trailLength, "trail", 0, "number of cells of fading trail to draw behind the snake, or 0 for none")
+	suddenDeath := flag.Int("sudden-death", 0, "seconds into a level before its walls start closing in, or 0 for never")
+	flag.BoolVar(&config.generate, "generate", false, "play procedurally generated mazes instead of the level files")
+	flag.IntVar(&config.seed, "seed", 0, "seed for generated mazes and other randomness, or 0 to pick one from the clock")
+	levelsDir := flag.String("levels", "", "load level-N.txt files from this directory instead of the built in levels")
+	recordFile := flag.String("record", "", "record the input of each game played to this file")
+	replayFile := flag.String("replay", "", "replay a game recorded with -record")
+	flag.BoolVar(&config.debug, "debug", false, "enable level testing tools, like ctrl+n to toggle noclip")
+	flag.Parse()
+	config.suddenDeathTime = *suddenDeath * FPS
+	if err := config.validateScreen(); err != nil {
+		log.Fatal(err)
+	}
+	if *levelsDir != "" {
+		config.levelsDir = *levelsDir
+		config.levels = os.DirFS(*levelsDir)
+	}
+	if config.seed == 0 {
+		config.seed = int(time.Now().UnixNano())
+	}
+	log.Printf("seed: %d", config.seed)
+	// a broken config file shouldn't stop the game from starting, so it's
+	// reported and the defaults are played with instead
+	config, err := loadConfigFile(config)
+	if err != nil {
+		log.Printf("loading config: %v; using defaults", err)
+	}
+
+	ebiten.SetWindowSize(config.screenWidth, config.screenHeight)
+	ebiten.SetWindowSizeLimits(config.screenWidth/2, config.screenHeight/2, -1, -1)
+	ebiten.SetWindowResizingMode(ebiten.WindowResizingModeEnabled)
+	ebiten.SetWindowTitle(TITLE)
+
+	game, err := NewGame(config)
+	if err != nil {
+		log.Fatal(err)
+	}
+	game.recordFile = *recordFile
+	if *replayFile != "" {
+		recording, err := loadRecording(*replayFile)
+		if err != nil {
+			log.Fatal(err)
+		}
+		if err := game.startReplay(recording); err != nil {
+			log.Fatal(err)
+		}
+	}
+
+	sounds.muted = !game.config.soundEnabled
+
+	if err := ebiten.RunGame(game); err != nil {
+		log.Fatal(err)
+	}
+	// keep the game that was in progress when the window was closed
+	game.saveRecording()
+}
+
+// Game owns all of the mutable game data and satisfies the ebitengine
+// interface. nothing outside of it reaches for the current State, so the
+// gameplay logic can be driven from any State value.
+type Game struct {
+	state State
+	// config is used for the next game started from the menu
+	config Config
+	font   Font
+	// sprites replace plain cells for the things that have one
+	sprites Sprites
+	// layout is the current size of the window
+	layout Layout
+	// windowedWidth and windowedHeight are the window size to restore when
+	// leaving fullscreen
+	windowedWidth  int
+	windowedHeight int
+	// levelIDs holds the ids of every level in the assets folder in ascending
+	// order, and menuSelection is the index of the one highlighted in the menu
+	levelIDs          Slice[int]
+	menuSelection     int
+	startBlinkCounter int
+	// optionsSelection is the index of the option highlighted on the options
+	// screen
+	optionsSelection int
+	// demo is the snake that circles the title on the start screen, and
+	// demoImage is what it's drawn to before being faded onto the screen
+	demo      State
+	demoImage *ebiten.Image
+	// highScore is the best score reached across all sessions, and dailyBest
+	// the best in today's daily challenge
+	highScore int
+	dailyBest int
+	// achievements are the ones unlocked across all sessions, and toasts
+	// queues the messages announcing newly unlocked ones
+	achievements Achievements
+	toasts       Slice[Toast]
+	// bestRuns holds the fastest run through each level this session, by
+	// level id
+	bestRuns map[int]Run
+	// showDebug toggles the F3 debug overlay
+	showDebug bool
+	// hideMinimap hides the minimap shown on large levels, toggled with N
+	hideMinimap bool
+	// swipe turns player one's snake with touch and mouse drags
+	swipe Swipe
+	// dpad turns player one's snake with an on-screen d-pad on touch screens
+	dpad DPad
+	// confirmQuit is set while the pause screen asks whether to quit
+	confirmQuit bool
+	// clock turns ticks into game frames while playing
+	clock Clock
+	// recordFile is where each game's input is saved when given, and recording
+	// is the input of the game in progress
+	recordFile string
+	recording  *Recording
+	// replay is the recording being played back instead of live input, and
+	// replayFrame is the index of its next frame
+	replay      *Recording
+	replayFrame int
+	// editorTool is what the mouse paints in the level editor, and
+	// editorMessage reports the result of the last save
+	editorTool    Tool
+	editorMessage string
+	// autopilot steers player one's snake when toggled with O, and
+	// autopilotPath is the route it last planned
+	autopilot     bool
+	autopilotPath Slice[Vec2]
+	// screenshotRequested is set by F12 in Update and handled at the end of
+	// the next Draw, once the frame is complete
+	screenshotRequested bool
+}
+
+// NewGame creates a new Game showing the menu, with the first available level
+// loaded and the high score read from disk. a high score that can't be read is
+// logged and treated as 0.
+func NewGame(config Config) (*Game, error) {
+	levelIDs, err := availableLevels(config.levels)
+	if err != nil {
+		return nil, err
+	}
+	if len(levelIDs) == 0 {
+		return nil, errors.New("no level files found")
+	}
+
+	state, err := NewState(levelIDs[0], config)
+	if err != nil {
+		return nil, err
+	}
+
+	highScore, err := loadHighScore()
+	if err != nil {
+		log.Printf("loading high score: %v", err)
+	}
+
+	achievements, err := loadAchievements()
+	if err != nil {
+		log.Printf("loading achievements: %v", err)
+	}
+
+	menuSelection := 0
+	for i, id := range levelIDs {
+		if id == config.startLevel {
+			menuSelection = i
+		}
+	}
+
+	return &Game{
+		state:             state,
+		config:            config,
+		font:              NewFont(),
+		sprites:           NewSprites(),
+		layout:            state.layout,
+		levelIDs:          levelIDs,
+		menuSelection:     menuSelection,
+		startBlinkCounter: 0,
+		demo:              NewDemoState(config),
+		demoImage:         ebiten.NewImage(DEMO_WIDTH*DEMO_CELL_SIZE, DEMO_HEIGHT*DEMO_CELL_SIZE),
+		highScore:         highScore,
+		achievements:      achievements,
+		bestRuns:          make(map[int]Run),
+	}, nil
+}
+
+// satisfies the main layout method from the [ebiten.Game] interface
+//
+// the screen always matches the window, and level cells are scaled to fill it
+//
+// [ebiten.Game]: https://pkg.go.dev/github.com/hajimehoshi/ebiten/v2#Game
+func (game *Game) Layout(outsideWidth, outsideHeight int) (screenWidth, screenHeight int) {
+	game.layout = NewLayout(outsideWidth, outsideHeight, game.state.config)
+	game.state.layout = game.layout
+	return outsideWidth, outsideHeight
+}
+
+// satisfies the main drawing method from [ebiten.Game]
+//
+// [ebiten.Game]: https://pkg.go.dev/github.com/hajimehoshi/ebiten/v2#Game
+func (game *Game) Draw(screen *ebiten.Image) {
+	background := game.config.palette.background
+	menu := game.state.status == StatusStarted || game.state.status == StatusOptions
+	if !menu && game.state.level.background.A > 0 {
+		background = game.state.level.background
+	}
+	screen.Fill(background)
+
+	switch game.state.status {
+	case StatusStarted:
+		game.drawStartScreen(screen)
+	case StatusPlaying, StatusPaused, StatusDying, StatusLevelComplete, StatusLost, StatusWon:
+		drawLevel(screen, &game.state, &game.sprites)
+		if game.autopilot && game.replay == nil {
+			drawPath(screen, &game.state, game.autopilotPath)
+		}
+		drawSnake(screen, &game.state, &game.sprites)
+		drawGhosts(screen, &game.state, &game.sprites)
+		drawPopups(screen, &game.state, &game.font.tiny)
+		game.drawMinimap(screen)
+		game.drawHUD(screen)
+		game.dpad.draw(screen, game.config.palette)
+	case StatusEditing:
+		game.drawEditor(screen)
+	case StatusOptions:
+		game.drawOptions(screen)
+	}
+	game.drawToast(screen)
+
+	if game.showDebug {
+		game.drawDebug(screen)
+	}
+	game.takeScreenshot(screen)
+}
+
+// drawCenteredText draws str horizontally centered on the screen with its top
+// edge at y, using the measured width of the text in the given face
+func drawCenteredText(screen *ebiten.Image, str string, face text.Face, y float64) {
+	width, _ := text.Measure(str, face, 0)
+
+	op := &text.DrawOptions{}
+	op.GeoM.Translate((float64(screen.Bounds().Dx())-width)/2, y)
+	text.Draw(screen, str, face, op)
+}
+
+// drawMenu draws the title, the list of levels to start from with the current
+// selection highlighted, and a blinking start prompt
+func (game *Game) drawMenu(screen *ebiten.Image) {
+	drawCenteredText(screen, TITLE, &game.font.regular, 80)
+
+	for i, id := range game.levelIDs {
+		item := "level " + strconv.Itoa(id)
+		itemColor := game.config.palette.menuItem
+		if i == game.menuSelection {
+			item = "> " + item + " <"
+			itemColor = game.config.palette.menuSelection
+		}
+
+		width, _ := text.Measure(item, &game.font.small, 0)
+		op := &text.DrawOptions{}
+		op.GeoM.Translate((float64(game.layout.width)-width)/2, float64(160+i*30))
+		op.ColorScale.ScaleWithColor(itemColor)
+		text.Draw(screen, item, &game.font.small, op)
+	}
+
+	if game.startBlinkCounter < 30 {
+		drawCenteredText(screen, "press "+game.config.keys.name(ActionStart)+" to start", &game.font.regular, float64(game.layout.height)-110)
+	}
+
+	drawCenteredText(screen, "mode (V): "+game.config.mode.String()+"   difficulty (D): "+game.config.difficulty.String(), &game.font.small, float64(game.layout.height)-65)
+	drawCenteredText(screen, "palette (C): "+game.config.palette.name, &game.font.small, float64(game.layout.height)-35)
+	drawCenteredText(screen, "press E to edit the selected level, or O for options", &game.font.tiny, 120)
+}
+
+// viewportOffset returns the cell the given world position is drawn at,
+// relative to the top left of the viewport. on axes where the level is at
+// least as big as the viewport the view wraps around the level like the snake
+// does, so positions just past the seam are drawn next to the edge they wrapped
+// from. on the other axes the level is centered.
+func viewportOffset(state *State, p Vec2) Vec2 {
+	offset := p.sub(Vec2{x: state.viewportX, y: state.viewportY})
+	if state.level.width >= state.layout.viewportWidth() {
+		offset.x = mod(offset.x, state.level.width)
+	}
+	if state.level.height >= state.layout.viewportHeight() {
+		offset.y = mod(offset.y, state.level.height)
+	}
+	return offset.add(viewportMargin(state))
+}
+
+// isVisible reports whether the given world position is inside the viewport
+func isVisible(state *State, p Vec2) bool {
+	offset := viewportOffset(state, p)
+	return offset.x >= 0 && offset.x < state.layout.viewportWidth() &&
+		offset.y >= 0 && offset.y < state.layout.viewportHeight()
+}
+
+// drawCell fills the grid cell at the given world position, offset by the
+// viewport
+func drawCell(screen *ebiten.Image, state *State, p Vec2, c color.Color) {
+	size := state.layout.cellSize
+	offset := viewportOffset(state, p)
+	vector.DrawFilledRect(screen, float32(offset.x*size), float32(offset.y*size), float32(size-1), float32(size-1), c, true)
+}
+
+// drawCellOutline strokes the border of the grid cell at the given world
+// position, offset by the viewport
+func drawCellOutline(screen *ebiten.Image, state *State, p Vec2, c color.Color) {
+	size := float32(state.layout.cellSize)
+	offset := viewportOffset(state, p)
+	vector.StrokeRect(screen, float32(offset.x)*size, float32(offset.y)*size, size-1, size-1, 2, c, true)
+}
+
+// drawArrow draws a chevron in the grid cell at the given world position,
+// pointing in the given direction
+func drawArrow(screen *ebiten.Image, state *State, p Vec2, direction Vec2, c color.Color) {
+	size := float32(state.layout.cellSize)
+	offset := viewportOffset(state, p)
+	centerX := float32(offset.x)*size + size/2
+	centerY := float32(offset.y)*size + size/2
+	reach := size / 3
+
+	tipX := centerX + float32(direction.x)*reach
+	tipY := centerY + float32(direction.y)*reach
+	// the two back corners sit behind the center on either side of the direction
+	backX := centerX - float32(direction.x)*reach
+	backY := centerY - float32(direction.y)*reach
+	sideX := float32(direction.y) * reach
+	sideY := float32(direction.x) * reach
+	vector.StrokeLine(screen, backX+sideX, backY+sideY, tipX, tipY, 2, c, true)
+	vector.StrokeLine(screen, backX-sideX, backY-sideY, tipX, tipY, 2, c, true)
+}
+
+// drawWall draws a wall cell as a rounded joint with arms reaching toward
+// each neighboring wall, so runs of walls render as connected pipes. the cell
+// is drawn at its level position rather than relative to the viewport, since
+// walls are drawn to the cached wall layer.
+func drawWall(dst *ebiten.Image, level *Level, p Vec2, size float32, c color.Color) {
+	thickness := size / 2
+	left := float32(p.x) * size
+	top := float32(p.y) * size
+	centerX, centerY := left+size/2, top+size/2
+
+	vector.DrawFilledCircle(dst, centerX, centerY, thickness/2, c, true)
+
+	mask := level.wallNeighbors(p)
+	if mask&WALL_UP != 0 {
+		vector.DrawFilledRect(dst, centerX-thickness/2, top, thickness, size/2, c, true)
+	}
+	if mask&WALL_DOWN != 0 {
+		vector.DrawFilledRect(dst, centerX-thickness/2, centerY, thickness, size/2, c, true)
+	}
+	if mask&WALL_LEFT != 0 {
+		vector.DrawFilledRect(dst, left, centerY-thickness/2, size/2, thickness, c, true)
+	}
+	if mask&WALL_RIGHT != 0 {
+		vector.DrawFilledRect(dst, centerX, centerY-thickness/2, size/2, thickness, c, true)
+	}
+}
+
+func drawLevel(screen *ebiten.Image, state *State, sprites *Sprites) {
+	drawWallLayer(screen, state)
+	drawGates(screen, state)
+	drawSuddenDeath(screen, state)
+
+	palette := state.config.palette
+
+	// draw portals as rings so they read as something to pass through
+	for position := range state.level.portals {
+		if isVisible(state, position) {
+			drawCellOutline(screen, state, position, palette.portal)
+		}
+	}
+
+	// draw arrow tiles as chevrons pointing the way they push
+	for position, direction := range state.level.arrows {
+		if isVisible(state, position) {
+			drawArrow(screen, state, position, direction, palette.arrow)
+		}
+	}
+
+	// draw power pellets as circles that pulse while the level is played
+	pulse := float32(0.75 + 0.25*math.Sin(float64(state.frame)*0.15))
+	for _, food := range state.level.foods {
+		if food.kind == FoodPower && isVisible(state, food.position) {
+			size := float32(state.layout.cellSize)
+			offset := viewportOffset(state, food.position)
+			vector.DrawFilledCircle(screen, (float32(offset.x)+0.5)*size, (float32(offset.y)+0.5)*size, size/2*pulse, palette.powerUp, true)
+		}
+	}
+
+	drawDigPickups(screen, state)
+	drawSlowPickups(screen, state)
+
+	// draw the other foods in the color of their kind, tinting plain food
+	// toward gold the more it is worth. food sprites pulse gently, a little
+	// out of step with the pellets.
+	foods := NewCellBatch()
+	for _, food := range state.level.foods {
+		if food.kind == FoodPower || !isVisible(state, food.position) {
+			continue
+		}
+		var foodColor color.RGBA
+		switch food.kind {
+		case FoodNormal:
+			worth := float64(state.level.foodValue(food.position)-1) / 8
+			foodColor = blendColor(palette.food, palette.valuableFood, worth)
+		case FoodShrink:
+			foodColor = palette.shrinkFood
+		case FoodBonus:
+			foodColor = palette.bonusFood
+		}
+		if sprites.food != nil {
+			scale := 0.9 + 0.1*math.Sin(float64(state.frame)*0.1)
+			drawSprite(screen, state, sprites.food, food.position, foodColor, scale, Vec2{})
+		} else {
+			foods.add(state, food.position, foodColor)
+		}
+	}
+	foods.draw(screen)
+	drawBonus(screen, state)
+
+	// draw exit, muted toward the walls while it's locked
+	if isVisible(state, state.level.exit) {
+		exitColor := palette.exit
+		if state.level.exitLocked() {
+			exitColor = blendColor(palette.exit, palette.wall, 0.5)
+		}
+		drawCell(screen, state, state.level.exit, exitColor)
+	}
+}
+
+func drawSnake(screen *ebiten.Image, state *State, sprites *Sprites) {
+	palette := state.config.palette
+	cells := NewCellBatch()
+	// head sprites and eyes are drawn after the bodies, so they aren't
+	// covered by a batch
+	type snakeHead struct {
+		position  Vec2
+		color     color.RGBA
+		direction Vec2
+	}
+	heads := NewSlice[snakeHead]()
+	drawGhostRun(screen, state)
+	for _, snake := range state.snakes {
+		// the snake blinks while the death animation plays
+		if state.status == StatusDying && state.dyingFrames%10 < 5 {
+			continue
+		}
+
+		snakeColor := palette.snake
+		if snake.player == 1 {
+			snakeColor = palette.rival
+		}
+
+		// the trail is drawn straight away so the body covers any of it the
+		// snake has come back over
+		for i, p := range snake.trail {
+			if isVisible(state, p) {
+				fade := 0.5 * float64(len(snake.trail)-i) / float64(len(snake.trail)+1)
+				// dimming the alpha along with the color keeps it premultiplied
+				trailColor := dimColor(snakeColor, fade)
+				trailColor.A = uint8(255 * fade)
+				drawCell(screen, state, p, trailColor)
+			}
+		}
+
+		headColor := snakeColor
+		bodyColor := dimColor(snakeColor, 0.8)
+		if isPowerUpShown(state) {
+			headColor = palette.powerUp
+			bodyColor = dimColor(palette.powerUp, 0.8)
+		}
+		if state.status == StatusDying || state.status == StatusLost {
+			headColor = palette.deadHead
+		}
+
+		head := snake.getHead()
+		if state.config.snakeStyle == SnakeStyleRounded {
+			drawRoundedSnake(screen, state, &snake, headColor, bodyColor, sprites.head == nil)
+			if isVisible(state, head) {
+				heads = append(heads, snakeHead{position: head, color: headColor, direction: headDirection(&snake)})
+			}
+			continue
+		}
+		for _, p := range snake.body {
+			if isVisible(state, p) {
+				if p == head {
+					heads = append(heads, snakeHead{position: p, color: headColor, direction: headDirection(&snake)})
+					if sprites.head == nil {
+						cells.add(state, p, headColor)
+					}
+				} else {
+					cells.add(state, p, bodyColor)
 				}
-				vector.DrawFilledRect(screen, float32((p.x-state.viewportX)*GRID_SIZE), float32(p.y*GRID_SIZE), GRID_SIZE-1, GRID_SIZE-1, headColor, true)
-			} else {
-				vector.DrawFilledRect(screen, float32((p.x-state.viewportX)*GRID_SIZE), float32(p.y*GRID_SIZE), GRID_SIZE-1, GRID_SIZE-1, bodyColor, true)
 			}
 		}
 	}
+	cells.draw(screen)
+	for _, head := range heads {
+		if sprites.head != nil {
+			drawSprite(screen, state, sprites.head, head.position, head.color, 1, head.direction)
+		} else {
+			drawEyes(screen, state, head.position, head.direction)
+		}
+	}
+}
+
+// drawEyes draws a pair of eyes on the head cell at p, toward its front edge
+// so they look the way the snake is facing
+func drawEyes(screen *ebiten.Image, state *State, p Vec2, direction Vec2) {
+	size := float32(state.layout.cellSize - 1)
+	offset := viewportOffset(state, p)
+	centerX := float32(offset.x*state.layout.cellSize) + size/2
+	centerY := float32(offset.y*state.layout.cellSize) + size/2
+
+	// forward is along direction and across is at right angles to it
+	forwardX, forwardY := float32(direction.x)*size/5, float32(direction.y)*size/5
+	acrossX, acrossY := float32(-direction.y)*size/4, float32(direction.x)*size/4
+	radius := size / 8
+	for _, side := range [2]float32{-1, 1} {
+		vector.DrawFilledCircle(screen, centerX+forwardX+side*acrossX, centerY+forwardY+side*acrossY, radius, state.config.palette.background, true)
+	}
+}
+
+// headDirection returns the way the snake's head faces: the direction of its
+// last step, or the one it is about to take before it has moved, or right
+// when it hasn't been given one yet
+func headDirection(snake *Snake) Vec2 {
+	if snake.prevDirection != (Vec2{}) {
+		return snake.prevDirection
+	}
+	if snake.direction != (Vec2{}) {
+		return snake.direction
+	}
+	return Vec2{x: 1, y: 0}
+}
+
+// drawPath faintly fills each visible cell along a path, such as the one the
+// autopilot is following
+func drawPath(screen *ebiten.Image, state *State, path Slice[Vec2]) {
+	// the color is dimmed along with its alpha to stay premultiplied
+	faint := dimColor(state.config.palette.snake, 0.25)
+	faint.A = 64
+
+	cells := NewCellBatch()
+	for _, p := range path {
+		if isVisible(state, p) {
+			cells.add(state, p, faint)
+		}
+	}
+	cells.draw(screen)
+}
+
+func drawGhosts(screen *ebiten.Image, state *State, sprites *Sprites) {
+	ghostColor := state.config.palette.ghost
+	if isPowerUpShown(state) {
+		ghostColor = state.config.palette.frightenedGhost
+	}
+	for _, ghost := range state.level.ghosts {
+		if !isVisible(state, ghost.position) {
+			continue
+		}
+		if sprites.ghost != nil {
+			drawSprite(screen, state, sprites.ghost, ghost.position, ghostColor, 1, Vec2{})
+		} else {
+			drawCell(screen, state, ghost.position, ghostColor)
+		}
+	}
+}
+
+// blendColor mixes from into to by the given amount between 0 and 1
+func blendColor(from color.RGBA, to color.RGBA, amount float64) color.RGBA {
+	mix := func(a, b uint8) uint8 {
+		return uint8(float64(a) + (float64(b)-float64(a))*amount)
+	}
+	return color.RGBA{
+		R: mix(from.R, to.R),
+		G: mix(from.G, to.G),
+		B: mix(from.B, to.B),
+		A: mix(from.A, to.A),
+	}
 }
 
 func dimColor(c color.RGBA, factor float64) color.RGBA {
@@ -417,77 +2282,481 @@ func dimColor(c color.RGBA, factor float64) color.RGBA {
 	}
 }
 
-func drawHUD(screen *ebiten.Image) {
-	// draw score
+func (game *Game) drawHUD(screen *ebiten.Image) {
+	// draw score, or each player's score in versus mode
 	op := &text.DrawOptions{}
 	op.GeoM.Translate(10, 25)
-	text.Draw(screen, "score: "+strconv.Itoa(state.score), &font.small, op)
+	if game.state.config.mode == ModeVersus {
+		text.Draw(screen, "P1: "+strconv.Itoa(game.state.snakes[0].score), &game.font.small, op)
+		op.GeoM.Translate(0, 25)
+		text.Draw(screen, "P2: "+strconv.Itoa(game.state.snakes[1].score), &game.font.small, op)
+	} else if game.state.practice {
+		text.Draw(screen, "practice", &game.font.small, op)
+	} else {
+		text.Draw(screen, "score: "+strconv.Itoa(game.state.score), &game.font.small, op)
+	}
+
+	// draw high score in the top right corner
+	highScore := game.highScore
+	if game.state.config.mode == ModeDaily {
+		highScore = game.dailyBest
+	}
+	highText := "high: " + strconv.Itoa(highScore)
+	highWidth, _ := text.Measure(highText, &game.font.small, 0)
+	highOp := &text.DrawOptions{}
+	highOp.GeoM.Translate(float64(game.layout.width)-highWidth-10, 25)
+	text.Draw(screen, highText, &game.font.small, highOp)
+
+	// draw the difficulty under the high score
+	difficultyText := game.state.config.difficulty.String()
+	difficultyWidth, _ := text.Measure(difficultyText, &game.font.small, 0)
+	difficultyOp := &text.DrawOptions{}
+	difficultyOp.GeoM.Translate(float64(game.layout.width)-difficultyWidth-10, 50)
+	text.Draw(screen, difficultyText, &game.font.small, difficultyOp)
+
+	// draw the level's title under the difficulty
+	titleText := game.state.level.title()
+	titleWidth, _ := text.Measure(titleText, &game.font.tiny, 0)
+	titleOp := &text.DrawOptions{}
+	titleOp.GeoM.Translate(float64(game.layout.width)-titleWidth-10, 75)
+	text.Draw(screen, titleText, &game.font.tiny, titleOp)
+
+	// announce the level in the middle of the screen as it starts, fading out
+	if game.state.bannerFrames > 0 {
+		bannerWidth, _ := text.Measure(titleText, &game.font.regular, 0)
+		bannerOp := &text.DrawOptions{}
+		bannerOp.GeoM.Translate((float64(game.layout.width)-bannerWidth)/2, float64(game.layout.height)/3)
+		bannerOp.ColorScale.ScaleAlpha(float32(game.state.bannerFrames) / LEVEL_BANNER_FRAMES)
+		text.Draw(screen, titleText, &game.font.regular, bannerOp)
+	}
+
+	// draw remaining lives, or how many times the snake has died in practice
+	if game.state.practice {
+		op.GeoM.Translate(0, 25)
+		text.Draw(screen, "deaths: "+strconv.Itoa(game.state.deaths), &game.font.small, op)
+	} else if game.state.config.mode != ModeVersus && game.state.config.mode != ModeZen {
+		op.GeoM.Translate(0, 25)
+		text.Draw(screen, "lives: "+strconv.Itoa(game.state.lives), &game.font.small, op)
+	}
+
+	// draw time played in endless mode, or time remaining rounded up to whole
+	// seconds on levels with a time limit outside of zen mode
+	if game.state.config.mode == ModeEndless {
+		seconds := game.state.frame / FPS
+		op.GeoM.Translate(0, 25)
+		text.Draw(screen, fmt.Sprintf("elapsed: %d:%02d", seconds/60, seconds%60), &game.font.small, op)
+	} else if game.state.level.timeLimit > 0 && game.state.config.mode != ModeZen {
+		op.GeoM.Translate(0, 25)
+		text.Draw(screen, "time: "+strconv.Itoa(secondsLeft(game.state.timeRemaining)), &game.font.small, op)
+	}
+
+	// draw dig charges
+	if game.state.digCharges > 0 {
+		op.GeoM.Translate(0, 25)
+		text.Draw(screen, "dig: "+strconv.Itoa(game.state.digCharges), &game.font.small, op)
+	}
+
+	// draw rewinds left
+	if game.state.rewindCharges > 0 && game.state.config.mode != ModeVersus {
+		op.GeoM.Translate(0, 25)
+		text.Draw(screen, "rewind: "+strconv.Itoa(game.state.rewindCharges), &game.font.small, op)
+	}
 
 	// draw power up timer
-	if state.powerUpTimer > 0 {
-		powerUpText := "power-up: " + strconv.Itoa(state.powerUpTimer/60) // Convert frames to seconds
+	if game.state.powerUpTimer > 0 {
+		powerUpText := "power-up: " + strconv.Itoa(secondsLeft(game.state.powerUpTimer))
+		op.GeoM.Translate(0, 25)
+		if isPowerUpShown(&game.state) {
+			text.Draw(screen, powerUpText, &game.font.small, op)
+		}
+	}
+
+	// draw slow motion timer
+	if game.state.slowMotionTimer > 0 {
 		op.GeoM.Translate(0, 25)
-		text.Draw(screen, powerUpText, &font.small, op)
+		text.Draw(screen, "slow: "+strconv.Itoa(secondsLeft(game.state.slowMotionTimer)), &game.font.small, op)
 	}
 
-	// draw end game message
-	if state.status == StatusLost || state.status == StatusWon {
+	// draw combo multiplier
+	if game.state.combo > 1 {
+		op.GeoM.Translate(0, 25)
+		text.Draw(screen, "combo: x"+strconv.Itoa(game.state.combo), &game.font.small, op)
+	}
+
+	// draw a badge while a recording is played back
+	if game.replay != nil {
+		drawCenteredText(screen, "REPLAY", &game.font.small, 25)
+	} else if game.autopilot {
+		drawCenteredText(screen, "AUTOPILOT", &game.font.small, 25)
+	} else if game.state.noclip {
+		drawCenteredText(screen, "NOCLIP", &game.font.small, 25)
+	}
+
+	// draw pause message
+	if game.state.status == StatusPaused {
 		// semi-transparent black background
-		vector.DrawFilledRect(screen, 0, 0, SCREEN_WIDTH, SCREEN_HEIGHT, color.RGBA{0, 0, 0, 128}, true)
+		vector.DrawFilledRect(screen, 0, 0, float32(game.layout.width), float32(game.layout.height), game.state.config.palette.overlay, true)
+
+		if game.confirmQuit {
+			drawCenteredText(screen, "quit to desktop?", &game.font.small, float64(game.layout.height)/2-25)
+			drawCenteredText(screen, "Y to quit, N to keep playing", &game.font.small, float64(game.layout.height)/2+25)
+		} else {
+			drawCenteredText(screen, "PAUSED", &game.font.small, float64(game.layout.height)/2-25)
+			drawCenteredText(screen, "press "+game.config.keys.name(ActionPause)+" to resume", &game.font.small, float64(game.layout.height)/2+25)
+			drawCenteredText(screen, "press Q to quit", &game.font.tiny, float64(game.layout.height)/2+60)
+		}
+	}
 
-		op := &text.DrawOptions{}
+	// draw level complete message
+	if game.state.status == StatusLevelComplete {
+		vector.DrawFilledRect(screen, 0, 0, float32(game.layout.width), float32(game.layout.height), game.state.config.palette.overlay, true)
+
+		drawCenteredText(screen, game.state.level.title()+" complete", &game.font.small, float64(game.layout.height)/2-25)
+		drawCenteredText(screen, "level score: "+strconv.Itoa(game.state.score-game.state.levelStartScore), &game.font.small, float64(game.layout.height)/2+10)
+		drawCenteredText(screen, "press "+game.config.keys.name(ActionStart)+" for next level", &game.font.small, float64(game.layout.height)/2+45)
+	}
+
+	// draw end game message
+	if game.state.status == StatusLost || game.state.status == StatusWon {
+		// semi-transparent black background
+		vector.DrawFilledRect(screen, 0, 0, float32(game.layout.width), float32(game.layout.height), game.state.config.palette.overlay, true)
 
 		message := "game over!"
-		if state.status == StatusWon {
+		if game.state.status == StatusWon {
 			message = "you win!"
 		}
+		if game.state.perfect {
+			message = "perfect!"
+		}
+		if game.state.config.mode == ModeVersus {
+			// versus games only end without a winner when time runs out
+			message = "draw!"
+			if game.state.status == StatusWon {
+				message = "player " + strconv.Itoa(game.state.winner+1) + " wins!"
+			}
+		}
+
+		drawCenteredText(screen, message, &game.font.small, float64(game.layout.height)/2-25)
+		if game.state.config.mode == ModeDaily {
+			drawCenteredText(screen, dailyShareText(&game.state), &game.font.tiny, float64(game.layout.height)/2+95)
+		}
+		if game.state.endFrames > RESTART_DELAY {
+			drawCenteredText(screen, "press "+game.config.keys.name(ActionRestart)+" to restart", &game.font.small, float64(game.layout.height)/2+25)
+		}
+		drawCenteredText(screen, "press Q for menu", &game.font.small, float64(game.layout.height)/2+60)
+	}
+}
 
-		messageWidth := float64(len(message)) * float64(font.small.Size)
-		op.GeoM.Translate((float64(SCREEN_WIDTH)-messageWidth)/2, float64(SCREEN_HEIGHT)/2-25)
-		text.Draw(screen, message, &font.small, op)
+// drawDebug draws frame timing and snake details in the bottom left corner
+func (game *Game) drawDebug(screen *ebiten.Image) {
+	lines := Slice[string]{
+		fmt.Sprintf("fps: %.1f", ebiten.ActualFPS()),
+		fmt.Sprintf("tps: %.1f", ebiten.ActualTPS()),
+		fmt.Sprintf("length: %d", len(game.state.snakes[0].body)),
+		fmt.Sprintf("viewport: %d,%d", game.state.viewportX, game.state.viewportY),
+		fmt.Sprintf("power-up: %d", game.state.powerUpTimer),
+	}
+	if len(game.state.snakes[0].body) > 0 {
+		head := game.state.snakes[0].getHead()
+		lines = append(lines, fmt.Sprintf("head: %d,%d", head.x, head.y))
+	}
 
-		restartText := "press R to restart"
-		restartWidth := float64(len(restartText)) * float64(font.small.Size)
-		op.GeoM.Reset()
-		op.GeoM.Translate((float64(SCREEN_WIDTH)-restartWidth)/2, float64(SCREEN_HEIGHT)/2+25)
-		text.Draw(screen, restartText, &font.small, op)
+	op := &text.DrawOptions{}
+	op.GeoM.Translate(10, float64(game.layout.height-len(lines)*20-10))
+	for _, line := range lines {
+		text.Draw(screen, line, &game.font.small, op)
+		op.GeoM.Translate(0, 20)
 	}
 }
 
 // satisfies the main update method from the [ebiten.Game] interface
 //
 // [ebiten.Game]: https://pkg.go.dev/github.com/hajimehoshi/ebiten/v2#Game
-func (*Game) Update() error {
-	switch state.status {
+func (game *Game) Update() error {
+	// muting is the same setting as sound on the options screen, so it shows
+	// there and is saved with it
+	if inpututil.IsKeyJustPressed(ebiten.KeyM) {
+		game.config.soundEnabled = !game.config.soundEnabled
+		sounds.muted = !game.config.soundEnabled
+	}
+	if inpututil.IsKeyJustPressed(ebiten.KeyF11) {
+		game.toggleFullscreen()
+	}
+	if inpututil.IsKeyJustPressed(ebiten.KeyF3) {
+		game.showDebug = !game.showDebug
+	}
+	// ctrl+n toggles noclip in -debug games instead, through readInput so
+	// it's recorded
+	if inpututil.IsKeyJustPressed(ebiten.KeyN) && !(game.config.debug && ebiten.IsKeyPressed(ebiten.KeyControl)) {
+		game.hideMinimap = !game.hideMinimap
+	}
+	if inpututil.IsKeyJustPressed(ebiten.KeyF12) {
+		game.screenshotRequested = true
+	}
+	defer func() {
+		game.playSounds()
+		sounds.updateMusic(game.state.status)
+	}()
+	game.updateToasts()
+
+	switch game.state.status {
 	case StatusStarted:
-		updateStartState()
+		return game.updateStartState()
 	case StatusPlaying:
-		updatePlayingState()
+		game.updatePlayingState()
+	case StatusPaused:
+		return game.updatePausedState()
+	case StatusDying:
+		game.updateDyingState()
+	case StatusLevelComplete:
+		game.updateLevelCompleteState()
 	case StatusLost, StatusWon:
-		updateEndState()
+		return game.updateEndState()
+	case StatusEditing:
+		game.updateEditorState()
+	case StatusOptions:
+		game.updateOptionsState()
+	}
+	return nil
+}
+
+// updateStartState moves the menu selection with the up and down keys, cycles
+// the palette with C and the game mode with V, opens the selected level in the
+// editor with E and the options screen with O, and starts a new game at the
+// selected level when start or Enter is pressed
+func (game *Game) updateStartState() error {
+	game.startBlinkCounter = (game.startBlinkCounter + 1) % 60
+	game.updateDemo()
+
+	up := isMenuDirectionPressed(game.config.keys, Vec2{x: 0, y: -1})
+	if up && game.menuSelection > 0 {
+		game.menuSelection--
+	}
+	down := isMenuDirectionPressed(game.config.keys, Vec2{x: 0, y: 1})
+	if down && game.menuSelection < len(game.levelIDs)-1 {
+		game.menuSelection++
+	}
+
+	if inpututil.IsKeyJustPressed(ebiten.KeyC) {
+		game.config.palette = nextPalette(game.config.palette)
+	}
+	if inpututil.IsKeyJustPressed(ebiten.KeyV) {
+		game.config.mode = game.config.mode.next()
+	}
+	if inpututil.IsKeyJustPressed(ebiten.KeyD) {
+		game.config.difficulty = game.config.difficulty.next()
+	}
+	if inpututil.IsKeyJustPressed(ebiten.KeyE) {
+		return game.startEditor(game.levelIDs[game.menuSelection])
+	}
+	if inpututil.IsKeyJustPressed(ebiten.KeyO) {
+		game.state.status = StatusOptions
+		game.optionsSelection = 0
+		return nil
+	}
+
+	// starting only on the frame the key goes down means a key still held from
+	// the end screen doesn't skip straight into another game
+	if inpututil.IsKeyJustPressed(game.config.keys.key(ActionStart)) || inpututil.IsKeyJustPressed(ebiten.KeyEnter) || isGamepadButtonJustPressed(GAMEPAD_START_BUTTON) {
+		return game.startGame(game.levelIDs[game.menuSelection])
 	}
 	return nil
 }
 
-func updateStartState() {
-	if ebiten.IsKeyPressed(ebiten.KeySpace) {
-		state.status = StatusPlaying
+// startGame starts playing a new game at the given level, recording its input
+// when -record was given
+func (game *Game) startGame(levelID int) error {
+	config := game.config
+	if config.mode == ModeDaily {
+		// the daily challenge is always its one level, whatever is selected
+		config = dailyConfig(config, time.Now())
+		levelID = 1
+		best, err := loadDailyScore(dailyDate(config.seed))
+		if err != nil {
+			log.Printf("loading daily score: %v", err)
+		}
+		game.dailyBest = best
+	}
+
+	newState, err := NewState(levelID, config)
+	if err != nil {
+		return err
 	}
-	startBlinkCounter = (startBlinkCounter + 1) % 60
+	newState.layout = game.layout
+	game.state = newState
+	game.state.status = StatusPlaying
+	game.autopilotPath = nil
+	game.loadBestRun()
+
+	if game.recordFile != "" {
+		recording := NewRecording(levelID, config)
+		game.recording = &recording
+	}
+	return nil
 }
 
-func updatePlayingState() {
-	handleInput()
-	state.snake.move()
-	updateViewport()
+// startReplay starts playing back the given recording from its first frame,
+// using the settings it was recorded with
+func (game *Game) startReplay(recording Recording) error {
+	newState, err := NewState(recording.level, recording.config(game.config))
+	if err != nil {
+		return err
+	}
+	newState.layout = game.layout
+	game.state = newState
+	game.state.status = StatusPlaying
+
+	game.replay = &recording
+	game.replayFrame = 0
+	game.recording = nil
+	return nil
+}
+
+func (game *Game) updatePlayingState() {
+	if inpututil.IsKeyJustPressed(game.config.keys.key(ActionPause)) {
+		game.state.status = StatusPaused
+		game.confirmQuit = false
+		return
+	}
+	if inpututil.IsKeyJustPressed(ebiten.KeyO) {
+		game.autopilot = !game.autopilot
+		game.autopilotPath = nil
+	}
+
+	for frames := game.clock.frames(ebiten.TPS()); frames > 0 && game.state.status == StatusPlaying; frames-- {
+		game.state.update(game.nextInput())
+	}
+	game.collectAchievements()
+	game.collectBestRun()
+	if game.state.status == StatusLost || game.state.status == StatusWon {
+		game.finishGame()
+	}
+	updateViewport(&game.state)
+}
+
+// updateDyingState plays the death animation. all input is ignored until it
+// has finished and the game is lost.
+func (game *Game) updateDyingState() {
+	for frames := game.clock.frames(ebiten.TPS()); frames > 0 && game.state.status == StatusDying; frames-- {
+		updateDying(&game.state)
+	}
+	if game.state.status == StatusLost {
+		game.finishGame()
+	}
+}
+
+// finishGame records the high score, or the day's best in the daily challenge,
+// outside of versus games, and saves the recording once a game has been won or
+// lost
+func (game *Game) finishGame() {
+	switch game.state.config.mode {
+	case ModeDaily:
+		game.recordDailyScore()
+	case ModeCampaign, ModeEndless:
+		game.recordHighScore()
+	}
+	game.saveRecording()
+}
+
+// updatePowerUp counts down an active power-up by one frame. it runs before
+// anything moves, so every step within a frame sees the same power-up state,
+// and a power-up picked up on one frame ends exactly powerUpTime frames later.
+func updatePowerUp(state *State) {
 	if state.powerUpTimer > 0 {
 		state.powerUpTimer -= 1
 	}
 }
 
-func updateEndState() {
-	if ebiten.IsKeyPressed(ebiten.KeyR) {
-		state = NewState()
-		state.status = StatusPlaying
+// isPowerUpShown reports whether the power-up should be drawn as active this
+// frame. it shows steadily until the last POWER_UP_WARNING_FRAMES, then
+// blinks to warn that it's about to run out.
+func isPowerUpShown(state *State) bool {
+	if state.powerUpTimer == 0 {
+		return false
 	}
+	return state.powerUpTimer > POWER_UP_WARNING_FRAMES || state.powerUpTimer%10 >= 5
+}
+
+// secondsLeft converts a countdown in frames to the whole seconds shown on the
+// HUD. it rounds up, so the last second shows 1 rather than 0.
+func secondsLeft(frames int) int {
+	return (frames + FPS - 1) / FPS
+}
+
+// updateTimeLimit counts down the level's time limit, if it has one, and ends
+// the game when it runs out. endless and zen games ignore the time limit.
+func updateTimeLimit(state *State) {
+	if state.level.timeLimit == 0 || state.config.mode == ModeEndless || state.config.mode == ModeZen {
+		return
+	}
+	state.timeRemaining -= 1
+	if state.timeRemaining <= 0 {
+		state.timeRemaining = 0
+		startDying(state)
+		state.playSound(SoundDie)
+	}
+}
+
+// updateLevelCompleteState loads the next level when start or Enter is
+// pressed. replays carry straight on, since the recording has no input for
+// the wait.
+func (game *Game) updateLevelCompleteState() {
+	if game.replay != nil || inpututil.IsKeyJustPressed(game.config.keys.key(ActionStart)) || inpututil.IsKeyJustPressed(ebiten.KeyEnter) || isGamepadButtonJustPressed(GAMEPAD_START_BUTTON) {
+		advanceLevel(&game.state)
+		game.loadBestRun()
+		game.autopilotPath = nil
+		updateViewport(&game.state)
+	}
+}
+
+// updatePausedState resumes the game when pause is pressed. Q asks whether to
+// quit to the desktop, and while that's asked only Y, which quits, and N or
+// Esc, which go back to the pause screen, do anything.
+func (game *Game) updatePausedState() error {
+	if game.confirmQuit {
+		if inpututil.IsKeyJustPressed(ebiten.KeyY) {
+			// the game in progress ends here, so its high score and
+			// recording are kept the same as if it had been lost
+			game.finishGame()
+			return ebiten.Termination
+		}
+		if inpututil.IsKeyJustPressed(ebiten.KeyN) || inpututil.IsKeyJustPressed(ebiten.KeyEscape) {
+			game.confirmQuit = false
+		}
+		return nil
+	}
+
+	if inpututil.IsKeyJustPressed(ebiten.KeyQ) {
+		game.confirmQuit = true
+		return nil
+	}
+	if inpututil.IsKeyJustPressed(game.config.keys.key(ActionPause)) {
+		game.state.status = StatusPlaying
+	}
+	return nil
+}
+
+// updateEndState restarts the level the game ended on when restart is pressed,
+// or returns to the menu when Q or Esc is pressed. restart is ignored for the
+// first RESTART_DELAY frames, so someone still mashing keys as the game ends
+// gets to read the end screen.
+func (game *Game) updateEndState() error {
+	game.state.endFrames++
+
+	if inpututil.IsKeyJustPressed(ebiten.KeyQ) || inpututil.IsKeyJustPressed(ebiten.KeyEscape) {
+		game.state.status = StatusStarted
+		game.replay = nil
+		return nil
+	}
+
+	if game.state.endFrames <= RESTART_DELAY {
+		return nil
+	}
+	if inpututil.IsKeyJustPressed(game.config.keys.key(ActionRestart)) || isGamepadButtonJustPressed(GAMEPAD_RESTART_BUTTON) {
+		if game.replay != nil {
+			return game.startReplay(*game.replay)
+		}
+		return game.startGame(game.state.level.id)
+	}
+	return nil
 }
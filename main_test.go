@@ -0,0 +1,1085 @@
+package main
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+// testLevels returns a level directory holding the given levels as
+// level-1.txt, level-2.txt, and so on
+func testLevels(levels ...string) fstest.MapFS {
+	files := fstest.MapFS{}
+	for i, level := range levels {
+		files[fmt.Sprintf("level-%d.txt", i+1)] = &fstest.MapFile{Data: []byte(level)}
+	}
+	return files
+}
+
+// newTestState starts a game on the first of the given levels with the
+// default config
+func newTestState(t *testing.T, levels ...string) State {
+	t.Helper()
+	config := DefaultConfig()
+	config.levels = testLevels(levels...)
+	state, err := NewState(1, config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return state
+}
+
+func TestRandomEmptyCellFullLevel(t *testing.T) {
+	tests := []struct {
+		name   string
+		level  string
+		want   Vec2
+		wantOK bool
+	}{
+		{"one free cell", "######\n#S.FE#\n######\n", Vec2{x: 2, y: 1}, true},
+		{"no free cells", "#####\n#SFE#\n#####\n", Vec2{}, false},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			state := newTestState(t, test.level)
+			for i := 0; i < 20; i++ {
+				position, ok := randomEmptyCell(&state)
+				if position != test.want || ok != test.wantOK {
+					t.Fatalf("randomEmptyCell = %v, %t, want %v, %t", position, ok, test.want, test.wantOK)
+				}
+			}
+		})
+	}
+}
+
+func TestRemoveAt(t *testing.T) {
+	tests := []struct {
+		name  string
+		slice Slice[int]
+		index int
+		want  Slice[int]
+	}{
+		{"first", NewSlice(1, 2, 3), 0, NewSlice(2, 3)},
+		{"middle", NewSlice(1, 2, 3, 4), 1, NewSlice(1, 3, 4)},
+		{"last", NewSlice(1, 2, 3), 2, NewSlice(1, 2)},
+		{"only", NewSlice(1), 0, Slice[int]{}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			original := append(NewSlice[int](), test.slice...)
+			got := test.slice.removeAt(test.index)
+			if !reflect.DeepEqual(got, test.want) {
+				t.Errorf("removeAt(%d) = %v, want %v", test.index, got, test.want)
+			}
+			// the slice removed from must still see its old contents
+			if !reflect.DeepEqual(test.slice, original) {
+				t.Errorf("original slice changed to %v, want %v", test.slice, original)
+			}
+		})
+	}
+}
+
+func TestEatLastFood(t *testing.T) {
+	state := newTestState(t,
+		"#####\n#SFE#\n#####\n",
+		"#####\n#S.F#\n##E##\n#####\n",
+	)
+
+	if status := state.Step(Vec2{x: 1, y: 0}); status != StatusPlaying {
+		t.Fatalf("status after first step = %v, want playing", status)
+	}
+	for i := 0; i < 100 && len(state.level.foods) > 0; i++ {
+		state.Step(Vec2{})
+	}
+	if len(state.level.foods) != 0 {
+		t.Fatal("the last food was never eaten")
+	}
+	if state.score == 0 {
+		t.Error("eating the last food didn't score")
+	}
+	if state.status != StatusPlaying {
+		t.Fatalf("status after eating the last food = %v, want playing", state.status)
+	}
+
+	for i := 0; i < 100 && state.status == StatusPlaying; i++ {
+		state.Step(Vec2{})
+	}
+	if state.status != StatusLevelComplete {
+		t.Fatalf("status at the exit = %v, want level complete", state.status)
+	}
+	state.Step(Vec2{})
+	if state.level.id != 2 || state.status != StatusPlaying {
+		t.Errorf("after the exit got level %d with status %v, want level 2 playing", state.level.id, state.status)
+	}
+}
+
+func TestParseLevelErrors(t *testing.T) {
+	tests := []struct {
+		name  string
+		level string
+		want  string
+	}{
+		{"empty", "", "invalid level 1: level is empty"},
+		{"missing start", "#####\n#.FE#\n#####\n", "invalid level 1: missing snake start 'S'"},
+		{"missing exit", "#####\n#SF.#\n#####\n", "invalid level 1: missing exit 'E'"},
+		{"no food", "#####\n#S.E#\n#####\n", "invalid level 1: missing food 'F'"},
+		{"ragged rows", "#####\n#SFE#\n####\n", "invalid level 1: row 3 has length 4, expected 5"},
+		{"bad time", ";time=soon\n#####\n#SFE#\n#####\n", `invalid level 1: bad time limit "soon"`},
+		{"negative time", ";time=-1\n#####\n#SFE#\n#####\n", `invalid level 1: bad time limit "-1"`},
+		{"bad par", ";par=x\n#####\n#SFE#\n#####\n", `invalid level 1: bad par time "x"`},
+		{"bad wrap", ";wrap=maybe\n#####\n#SFE#\n#####\n", `invalid level 1: bad wrap "maybe"`},
+		{"bad allfood", ";allfood=all\n#####\n#SFE#\n#####\n", `invalid level 1: bad allfood "all"`},
+		{"zero gate period", ";gate=0\n#####\n#SFE#\n#####\n", `invalid level 1: bad gate period "0"`},
+		{"bad background", ";bg=#12\n#####\n#SFE#\n#####\n", `invalid level 1: bad color "#12": expected #rrggbb`},
+		{"only metadata", ";name=nothing\n; a comment\n", "invalid level 1: level is empty"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := parseLevel(1, test.level)
+			if err == nil {
+				t.Fatal("parseLevel returned no error")
+			}
+			if err.Error() != test.want {
+				t.Errorf("error = %q, want %q", err, test.want)
+			}
+		})
+	}
+}
+
+func TestParseLevelCorners(t *testing.T) {
+	tests := []struct {
+		name     string
+		level    string
+		entrance Vec2
+		exit     Vec2
+	}{
+		{"start at the origin", "SFE\n...\n", Vec2{x: 0, y: 0}, Vec2{x: 2, y: 0}},
+		{"exit at the origin", "EFS\n...\n", Vec2{x: 2, y: 0}, Vec2{x: 0, y: 0}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			level, err := parseLevel(1, test.level)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if level.entrance != test.entrance || level.exit != test.exit {
+				t.Errorf("entrance %v and exit %v, want %v and %v", level.entrance, level.exit, test.entrance, test.exit)
+			}
+		})
+	}
+}
+
+func TestParseLevel(t *testing.T) {
+	level, err := parseLevel(1, "#####\n#SFE#\n#####\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if level.width != 5 || level.height != 3 {
+		t.Errorf("size = %dx%d, want 5x3", level.width, level.height)
+	}
+	if level.entrance != (Vec2{x: 1, y: 1}) || level.exit != (Vec2{x: 3, y: 1}) {
+		t.Errorf("entrance %v and exit %v, want {1 1} and {3 1}", level.entrance, level.exit)
+	}
+	if len(level.foods) != 1 || level.foods[0].position != (Vec2{x: 2, y: 1}) {
+		t.Errorf("foods = %v, want one at {2 1}", level.foods)
+	}
+}
+
+func TestParseLevelMetadata(t *testing.T) {
+	tests := []struct {
+		name      string
+		level     string
+		wantName  string
+		wantTime  int
+		wantSolid bool
+	}{
+		{"none", "#####\n#SFE#\n#####\n", "", 0, false},
+		{"comments", "; a comment\n#####\n;another = one\n#SFE#\n#####\n", "", 0, false},
+		{"unknown key", ";author=someone\n#####\n#SFE#\n#####\n", "", 0, false},
+		{"known keys", ";name=Spooky Cavern\n;time=60\n;wrap=false\n#####\n#SFE#\n#####\n", "Spooky Cavern", 60, true},
+		{"spaces and blank lines", "; name = Spooky Cavern \n\n#####\n\n#SFE#\n#####\n", "Spooky Cavern", 0, false},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			level, err := parseLevel(1, test.level)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if level.width != 5 || level.height != 3 {
+				t.Errorf("size = %dx%d, want 5x3 with the metadata left out of the grid", level.width, level.height)
+			}
+			if level.name != test.wantName || level.timeLimit != test.wantTime || level.solidEdges != test.wantSolid {
+				t.Errorf("name %q, time %d, solid edges %v, want %q, %d, %v", level.name, level.timeLimit, level.solidEdges, test.wantName, test.wantTime, test.wantSolid)
+			}
+		})
+	}
+}
+
+func TestNewStateReturnsLevelErrors(t *testing.T) {
+	config := DefaultConfig()
+	config.levels = testLevels("#####\n#S.E#\n#####\n")
+	if _, err := NewState(1, config); err == nil {
+		t.Error("NewState accepted a level with no food")
+	}
+	if _, err := NewState(2, config); err == nil {
+		t.Error("NewState accepted a level that doesn't exist")
+	}
+}
+
+func TestParseLevelRaggedRows(t *testing.T) {
+	tests := []struct {
+		name  string
+		level string
+		want  string
+	}{
+		{"longer row", "#####\n#SFE##\n#####\n", "invalid level 1: row 2 has length 6, expected 5"},
+		{"shorter row", "#####\n#SFE\n#####\n", "invalid level 1: row 2 has length 4, expected 5"},
+		{"short first row", "####\n#SFE#\n#####\n", "invalid level 1: row 2 has length 5, expected 4"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := parseLevel(1, test.level)
+			if err == nil || err.Error() != test.want {
+				t.Errorf("error = %v, want %q", err, test.want)
+			}
+		})
+	}
+}
+
+func TestIsSolvable(t *testing.T) {
+	tests := []struct {
+		name     string
+		level    string
+		solvable bool
+	}{
+		{"open", "#####\n#SFE#\n#####\n", true},
+		{"exit walled off", "######\n#SF#E#\n######\n", false},
+		{"food walled off", "######\n#SE#F#\n######\n", false},
+		{"through the wrap", "#####\nFS#E.\n#####\n", true},
+		{"wrap turned off", ";wrap=false\n#####\nFS#E.\n#####\n", false},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			level, err := parseLevel(1, test.level)
+			if test.solvable && (err != nil || !level.IsSolvable()) {
+				t.Errorf("solvable level rejected: %v", err)
+			}
+			if !test.solvable && (err == nil || err.Error() != "invalid level 1: exit or food can't be reached from the snake start") {
+				t.Errorf("error = %v, want the level to be unsolvable", err)
+			}
+		})
+	}
+}
+
+func TestFirstDirection(t *testing.T) {
+	level := "#######\n#F....#\n#.....#\n#..S..#\n#.....#\n#....E#\n#######\n"
+	for _, direction := range directions {
+		t.Run(fmt.Sprint(direction), func(t *testing.T) {
+			state := newTestState(t, level)
+			start := state.snakes[0].getHead()
+			lives := state.lives
+
+			state.Step(direction)
+			for i := 0; i < 100 && state.snakes[0].getHead() == start; i++ {
+				state.Step(Vec2{})
+			}
+			if head := state.snakes[0].getHead(); head != start.add(direction) {
+				t.Errorf("head = %v after the first move, want %v", head, start.add(direction))
+			}
+			if state.status != StatusPlaying || state.lives != lives {
+				t.Errorf("status %v with %d lives after the first move, want playing with %d", state.status, state.lives, lives)
+			}
+		})
+	}
+}
+
+func TestCompleteLevel(t *testing.T) {
+	level := "#####\n#SFE#\n#####\n"
+	tests := []struct {
+		name   string
+		levels []string
+		mode   Mode
+		want   Status
+	}{
+		{"last level", []string{level}, ModeCampaign, StatusWon},
+		{"more levels", []string{level, level}, ModeCampaign, StatusLevelComplete},
+		{"daily challenge", []string{level, level}, ModeDaily, StatusWon},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			state := newTestState(t, test.levels...)
+			state.config.mode = test.mode
+			completeLevel(&state)
+			if state.status != test.want {
+				t.Errorf("status = %v, want %v", state.status, test.want)
+			}
+		})
+	}
+}
+
+func TestRandomEmptyCellReachable(t *testing.T) {
+	level := "##########\n#S..FE#..#\n##########\n"
+	tests := []struct {
+		name string
+		head Vec2
+		want []Vec2
+	}{
+		{"from the entrance", Vec2{x: 1, y: 1}, []Vec2{{x: 2, y: 1}, {x: 3, y: 1}}},
+		// sudden death can wall the snake off from the entrance, so the cells
+		// it can still reach are used instead
+		{"walled off from the entrance", Vec2{x: 7, y: 1}, []Vec2{{x: 8, y: 1}}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			state := newTestState(t, level)
+			state.snakes[0].body = NewSlice(test.head)
+			picked := map[Vec2]bool{}
+			for i := 0; i < 200; i++ {
+				position, ok := randomEmptyCell(&state)
+				if !ok {
+					t.Fatal("no empty cell found")
+				}
+				picked[position] = true
+			}
+			want := map[Vec2]bool{}
+			for _, position := range test.want {
+				want[position] = true
+			}
+			if !reflect.DeepEqual(picked, want) {
+				t.Errorf("picked %v, want %v", picked, want)
+			}
+		})
+	}
+}
+
+func TestNewStateChecksLaterLevels(t *testing.T) {
+	config := DefaultConfig()
+	config.levels = testLevels("#####\n#SFE#\n#####\n", "#####\n#S.E#\n#####\n")
+	_, err := NewState(1, config)
+	if err == nil || err.Error() != "invalid level 2: missing food 'F'" {
+		t.Errorf("error = %v, want level 2 reported before the game starts", err)
+	}
+}
+
+func TestAdvanceLevel(t *testing.T) {
+	state := newTestState(t, "#####\n#SFE#\n#####\n", "######\n#S.FE#\n######\n")
+	completeLevel(&state)
+	advanceLevel(&state)
+	if state.level.id != 2 || state.level.width != 6 || len(state.upcoming) != 0 {
+		t.Errorf("level %d of width %d with %d to come, want level 2 of width 6 and none after", state.level.id, state.level.width, len(state.upcoming))
+	}
+	completeLevel(&state)
+	if state.status != StatusWon {
+		t.Errorf("status = %v after the last level, want won", state.status)
+	}
+}
+
+func TestVec2(t *testing.T) {
+	tests := []struct {
+		name string
+		got  interface{}
+		want interface{}
+	}{
+		{"add", Vec2{x: 1, y: 2}.add(Vec2{x: 3, y: -4}), Vec2{x: 4, y: -2}},
+		{"sub", Vec2{x: 1, y: 2}.sub(Vec2{x: 3, y: -4}), Vec2{x: -2, y: 6}},
+		{"scale", Vec2{x: 1, y: -2}.scale(3), Vec2{x: 3, y: -6}},
+		{"scale by zero", Vec2{x: 5, y: 7}.scale(0), Vec2{}},
+		{"manhattan", Vec2{x: 1, y: 2}.manhattan(Vec2{x: 4, y: -2}), 7},
+		{"manhattan to itself", Vec2{x: 3, y: 3}.manhattan(Vec2{x: 3, y: 3}), 0},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if test.got != test.want {
+				t.Errorf("got %v, want %v", test.got, test.want)
+			}
+		})
+	}
+}
+
+func TestWrappedDelta(t *testing.T) {
+	wrapping := Level{width: 10, height: 6}
+	solid := Level{width: 10, height: 6, solidEdges: true}
+	tests := []struct {
+		name  string
+		level Level
+		a, b  Vec2
+		want  Vec2
+	}{
+		{"neighbours", wrapping, Vec2{x: 2, y: 2}, Vec2{x: 3, y: 2}, Vec2{x: 1, y: 0}},
+		{"across the right edge", wrapping, Vec2{x: 0, y: 2}, Vec2{x: 9, y: 2}, Vec2{x: -1, y: 0}},
+		{"across the left edge", wrapping, Vec2{x: 9, y: 2}, Vec2{x: 0, y: 2}, Vec2{x: 1, y: 0}},
+		{"across the bottom edge", wrapping, Vec2{x: 4, y: 0}, Vec2{x: 4, y: 5}, Vec2{x: 0, y: -1}},
+		{"across both edges", wrapping, Vec2{x: 9, y: 5}, Vec2{x: 0, y: 0}, Vec2{x: 1, y: 1}},
+		// exactly half way round either way is as short, and isn't wrapped
+		{"half way across", wrapping, Vec2{x: 0, y: 0}, Vec2{x: 5, y: 3}, Vec2{x: 5, y: 3}},
+		{"half way back", wrapping, Vec2{x: 5, y: 3}, Vec2{x: 0, y: 0}, Vec2{x: -5, y: -3}},
+		{"solid edges", solid, Vec2{x: 0, y: 0}, Vec2{x: 9, y: 5}, Vec2{x: 9, y: 5}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := test.level.wrappedDelta(test.a, test.b); got != test.want {
+				t.Errorf("wrappedDelta(%v, %v) = %v, want %v", test.a, test.b, got, test.want)
+			}
+			distance := abs(test.want.x) + abs(test.want.y)
+			if got := test.level.wrappedDistance(test.a, test.b); got != distance {
+				t.Errorf("wrappedDistance(%v, %v) = %d, want %d", test.a, test.b, got, distance)
+			}
+		})
+	}
+}
+
+// stepUntilMoved steps the state toward direction, then keeps it on course,
+// until player one's head has moved
+func stepUntilMoved(t *testing.T, state *State, direction Vec2) {
+	t.Helper()
+	head := state.snakes[0].getHead()
+	state.Step(direction)
+	for i := 0; i < 100 && state.snakes[0].getHead() == head && state.status == StatusPlaying; i++ {
+		state.Step(Vec2{})
+	}
+	if state.snakes[0].getHead() == head {
+		t.Fatal("the snake never moved")
+	}
+}
+
+func TestMoveInterval(t *testing.T) {
+	for _, interval := range []int{1, 3, 7} {
+		t.Run(fmt.Sprint(interval), func(t *testing.T) {
+			config := DefaultConfig()
+			config.levels = testLevels("################\n#S...........FE#\n################\n")
+			config.moveInterval = interval
+			config.minMoveInterval = 1
+			state, err := NewState(1, config)
+			if err != nil {
+				t.Fatal(err)
+			}
+			state.Step(Vec2{x: 1, y: 0})
+			for i := 1; i < 3*interval; i++ {
+				state.Step(Vec2{})
+			}
+			if head := state.snakes[0].getHead(); head != (Vec2{x: 4, y: 1}) {
+				t.Errorf("head = %v after %d frames, want 3 steps to {4 1}", head, 3*interval)
+			}
+		})
+	}
+}
+
+func TestQueuedTurns(t *testing.T) {
+	state := newTestState(t, "#######\n#.....#\n#.....#\n#.S...#\n#.....#\n#F...E#\n#######\n")
+	stepUntilMoved(t, &state, Vec2{x: 1, y: 0})
+	// both turns are given before the next step is due
+	state.Step(Vec2{x: 0, y: -1})
+	state.Step(Vec2{x: -1, y: 0})
+	if len(state.snakes[0].inputQueue) != 2 {
+		t.Fatalf("input queue = %v, want both turns waiting", state.snakes[0].inputQueue)
+	}
+	stepUntilMoved(t, &state, Vec2{})
+	if head := state.snakes[0].getHead(); head != (Vec2{x: 3, y: 2}) {
+		t.Errorf("head = %v after the first turn, want {3 2}", head)
+	}
+	stepUntilMoved(t, &state, Vec2{})
+	if head := state.snakes[0].getHead(); head != (Vec2{x: 2, y: 2}) {
+		t.Errorf("head = %v after the second turn, want {2 2}", head)
+	}
+}
+
+func TestTurnUsesNewDirection(t *testing.T) {
+	state := newTestState(t, "#######\n#.....#\n#.....#\n#.S...#\n#.....#\n#F...E#\n#######\n")
+	stepUntilMoved(t, &state, Vec2{x: 1, y: 0})
+	stepUntilMoved(t, &state, Vec2{x: 0, y: 1})
+	snake := state.snakes[0]
+	if head := snake.getHead(); head != (Vec2{x: 3, y: 4}) {
+		t.Errorf("head = %v after turning down, want {3 4} rather than carrying on right", head)
+	}
+	if snake.prevDirection != (Vec2{x: 0, y: 1}) {
+		t.Errorf("prevDirection = %v, want the turn {0 1}", snake.prevDirection)
+	}
+}
+
+func TestGhostChase(t *testing.T) {
+	state := newTestState(t, "#######\n#S..G.#\n#F...E#\n#######\n")
+	ghost := state.level.ghosts[0].position
+	lives := state.lives
+
+	for i := 0; i < 1000 && state.level.ghosts[0].position == ghost; i++ {
+		state.Step(Vec2{})
+	}
+	head := state.snakes[0].getHead()
+	if state.level.wrappedDistance(state.level.ghosts[0].position, head) >= state.level.wrappedDistance(ghost, head) {
+		t.Errorf("ghost moved from %v to %v, want it closer to the head at %v", ghost, state.level.ghosts[0].position, head)
+	}
+
+	for i := 0; i < 1000 && state.lives == lives; i++ {
+		state.Step(Vec2{})
+	}
+	if state.lives != lives-1 {
+		t.Errorf("lives = %d, want one lost to the ghost", state.lives)
+	}
+	if state.level.ghosts[0].position != head {
+		t.Errorf("ghost at %v when the life was lost, want it on the head at %v", state.level.ghosts[0].position, head)
+	}
+}
+
+func TestViewportFollowsDown(t *testing.T) {
+	level := "#######\n#S....#\n" + strings.Repeat("#.....#\n", 16) + "#F...E#\n#######\n"
+	state := newTestState(t, level)
+	state.layout = Layout{width: 100, height: 50, cellSize: 10}
+	viewportHeight := state.layout.viewportHeight()
+
+	state.Step(Vec2{x: 0, y: 1})
+	for i := 0; i < 1000 && state.snakes[0].getHead().y < 12; i++ {
+		state.Step(Vec2{})
+		updateViewport(&state)
+		offset := viewportOffset(&state, state.snakes[0].getHead())
+		if offset.y < 0 || offset.y >= viewportHeight {
+			t.Fatalf("head %v drawn at row %d, outside the %d rows shown", state.snakes[0].getHead(), offset.y, viewportHeight)
+		}
+	}
+	if state.viewportY == 0 {
+		t.Error("viewportY didn't follow the head down")
+	}
+	if state.viewportX != 0 {
+		t.Errorf("viewportX = %d on a level narrower than the viewport, want 0", state.viewportX)
+	}
+}
+
+func TestCombo(t *testing.T) {
+	state := newTestState(t, "##################\n#SFF..........F.E#\n##################\n")
+	stepUntilMoved(t, &state, Vec2{x: 1, y: 0})
+	stepUntilMoved(t, &state, Vec2{})
+	if state.combo != 2 || state.score != 1+2 {
+		t.Errorf("combo %d and score %d after two quick foods, want 2 and 3", state.combo, state.score)
+	}
+
+	for i := 0; i < 1000 && len(state.level.foods) > 0; i++ {
+		state.Step(Vec2{})
+		if len(state.level.foods) > 0 && state.frame-state.lastEatFrame > state.config.comboWindow && state.combo != 0 {
+			t.Fatalf("combo = %d once the window lapsed, want 0", state.combo)
+		}
+	}
+	if state.combo != 1 || state.score != 3+1 {
+		t.Errorf("combo %d and score %d after a slow food, want 1 and 4", state.combo, state.score)
+	}
+}
+
+func TestFoodValue(t *testing.T) {
+	state := newTestState(t, "#######\n#S5F.E#\n#######\n")
+	stepUntilMoved(t, &state, Vec2{x: 1, y: 0})
+	if state.score != 5 {
+		t.Errorf("score = %d after eating a 5, want 5", state.score)
+	}
+	if value := state.level.foodValue(Vec2{x: 3, y: 1}); value != 1 {
+		t.Errorf("plain food is worth %d, want 1", value)
+	}
+}
+
+func TestLevelStringRoundTrip(t *testing.T) {
+	text := ";name=everything\n;time=60\n;par=30\n;wrap=false\n;allfood=true\n;bg=#102030\n;gate=3\n" +
+		"##########\n" +
+		"#S 5 9 F #\n" +
+		"#a P - $ #\n" +
+		"# D Z G a#\n" +
+		"#> v T t #\n" +
+		"#b   <^ E#\n" +
+		"#   b    #\n" +
+		"##########\n"
+	level, err := parseLevel(1, text)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := level.String(); got != text {
+		t.Errorf("String() =\n%s\nwant\n%s", got, text)
+	}
+	parsed, err := parseLevel(1, level.String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(parsed.foodValues, level.foodValues) {
+		t.Errorf("food values after a round trip = %v, want %v", parsed.foodValues, level.foodValues)
+	}
+}
+
+func TestViewportOffset(t *testing.T) {
+	tests := []struct {
+		name     string
+		size     Vec2
+		viewport Vec2
+		position Vec2
+		want     Vec2
+		visible  bool
+	}{
+		{"top left corner", Vec2{x: 20, y: 20}, Vec2{}, Vec2{x: 0, y: 0}, Vec2{x: 0, y: 0}, true},
+		{"last cell shown", Vec2{x: 20, y: 20}, Vec2{}, Vec2{x: 9, y: 9}, Vec2{x: 9, y: 9}, true},
+		{"first cell past the right edge", Vec2{x: 20, y: 20}, Vec2{}, Vec2{x: 10, y: 0}, Vec2{x: 10, y: 0}, false},
+		{"first cell past the bottom edge", Vec2{x: 20, y: 20}, Vec2{}, Vec2{x: 0, y: 10}, Vec2{x: 0, y: 10}, false},
+		{"scrolled view's first cell", Vec2{x: 20, y: 20}, Vec2{x: 15, y: 15}, Vec2{x: 15, y: 15}, Vec2{x: 0, y: 0}, true},
+		{"just before a scrolled view", Vec2{x: 20, y: 20}, Vec2{x: 15, y: 15}, Vec2{x: 14, y: 14}, Vec2{x: 19, y: 19}, false},
+		{"last cell past the seam", Vec2{x: 20, y: 20}, Vec2{x: 15, y: 15}, Vec2{x: 4, y: 4}, Vec2{x: 9, y: 9}, true},
+		{"first cell hidden past the seam", Vec2{x: 20, y: 20}, Vec2{x: 15, y: 15}, Vec2{x: 5, y: 5}, Vec2{x: 10, y: 10}, false},
+		{"narrow level's first cell", Vec2{x: 6, y: 6}, Vec2{}, Vec2{x: 0, y: 0}, Vec2{x: 2, y: 2}, true},
+		{"narrow level's last cell", Vec2{x: 6, y: 6}, Vec2{}, Vec2{x: 5, y: 5}, Vec2{x: 7, y: 7}, true},
+		{"level exactly the viewport size", Vec2{x: 10, y: 10}, Vec2{}, Vec2{x: 9, y: 9}, Vec2{x: 9, y: 9}, true},
+		{"wide but short level", Vec2{x: 20, y: 4}, Vec2{}, Vec2{x: 19, y: 3}, Vec2{x: 19, y: 6}, false},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			state := State{
+				level:     Level{width: test.size.x, height: test.size.y},
+				layout:    Layout{width: 100, height: 100, cellSize: 10},
+				viewportX: test.viewport.x,
+				viewportY: test.viewport.y,
+			}
+			if got := viewportOffset(&state, test.position); got != test.want {
+				t.Errorf("viewportOffset(%v) = %v, want %v", test.position, got, test.want)
+			}
+			if got := isVisible(&state, test.position); got != test.visible {
+				t.Errorf("isVisible(%v) = %v, want %v", test.position, got, test.visible)
+			}
+		})
+	}
+}
+
+func TestSameSeedSpawnsTheSame(t *testing.T) {
+	newSeededState := func(seed int) State {
+		config := DefaultConfig()
+		config.generate = true
+		config.seed = seed
+		state, err := NewState(1, config)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for i := 0; i < 5; i++ {
+			spawnFood(&state)
+		}
+		return state
+	}
+
+	first, second := newSeededState(42), newSeededState(42)
+	if first.level.String() != second.level.String() {
+		t.Errorf("seed 42 gave different levels\n%s\n%s", first.level.String(), second.level.String())
+	}
+	if !reflect.DeepEqual(first.level.foods, second.level.foods) {
+		t.Errorf("seed 42 spawned food at %v and %v", first.level.foods, second.level.foods)
+	}
+	if !reflect.DeepEqual(first.level.ghosts, second.level.ghosts) {
+		t.Errorf("seed 42 placed ghosts at %v and %v", first.level.ghosts, second.level.ghosts)
+	}
+
+	other := newSeededState(43)
+	if reflect.DeepEqual(first.level.foods, other.level.foods) {
+		t.Errorf("seeds 42 and 43 spawned the same food at %v", first.level.foods)
+	}
+}
+
+func TestPortal(t *testing.T) {
+	state := newTestState(t, "#########\n#S.a.a..#\n#F.....E#\n#########\n")
+	stepUntilMoved(t, &state, Vec2{x: 1, y: 0})
+	stepUntilMoved(t, &state, Vec2{})
+	if head := state.snakes[0].getHead(); head != (Vec2{x: 5, y: 1}) {
+		t.Errorf("head = %v after entering the portal at {3 1}, want its partner {5 1}", head)
+	}
+	stepUntilMoved(t, &state, Vec2{})
+	if head := state.snakes[0].getHead(); head != (Vec2{x: 6, y: 1}) {
+		t.Errorf("head = %v after leaving the portal, want {6 1}", head)
+	}
+}
+
+func TestPortalPartnerCoveredByBody(t *testing.T) {
+	state := newTestState(t, "#########\n#S.a.a..#\n#F.....E#\n#########\n")
+	// the body curls round under the portals so its tail covers the partner
+	state.snakes[0].body = NewSlice(
+		Vec2{x: 2, y: 1}, Vec2{x: 2, y: 2}, Vec2{x: 3, y: 2},
+		Vec2{x: 4, y: 2}, Vec2{x: 5, y: 2}, Vec2{x: 5, y: 1},
+	)
+	lives := state.lives
+
+	state.Step(Vec2{x: 1, y: 0})
+	for i := 0; i < 100 && state.lives == lives; i++ {
+		state.Step(Vec2{})
+	}
+	if state.lives != lives-1 {
+		t.Errorf("lives = %d, want one lost coming out of the portal into the body", state.lives)
+	}
+}
+
+func TestArrowOverridesInput(t *testing.T) {
+	state := newTestState(t, "#######\n#.....#\n#S.>..#\n#F...E#\n#######\n")
+	stepUntilMoved(t, &state, Vec2{x: 1, y: 0})
+	stepUntilMoved(t, &state, Vec2{})
+	if head := state.snakes[0].getHead(); head != (Vec2{x: 3, y: 2}) {
+		t.Fatalf("head = %v, want it on the arrow at {3 2}", head)
+	}
+	// turning up on the arrow is overridden and the snake carries on right
+	stepUntilMoved(t, &state, Vec2{x: 0, y: -1})
+	if head := state.snakes[0].getHead(); head != (Vec2{x: 4, y: 2}) {
+		t.Errorf("head = %v after turning up on a '>' tile, want {4 2}", head)
+	}
+}
+
+func TestLongSnakeReachesExit(t *testing.T) {
+	state := newTestState(t, "#########\n#SFFFFE.#\n#########\n", "#####\n#SFE#\n#####\n")
+	lives := state.lives
+	stepUntilMoved(t, &state, Vec2{x: 1, y: 0})
+	for i := 0; i < 1000 && state.status == StatusPlaying; i++ {
+		state.Step(Vec2{})
+	}
+	if state.status != StatusLevelComplete {
+		t.Fatalf("status = %v, want level complete", state.status)
+	}
+	if state.lives != lives {
+		t.Errorf("lives = %d, want %d with nothing hit", state.lives, lives)
+	}
+
+	body := state.snakes[0].body
+	if len(body) != 5 {
+		t.Errorf("body = %v at the exit, want 5 segments after eating 4 foods", body)
+	}
+	if body[0] != state.level.exit {
+		t.Errorf("head = %v, want it on the exit at %v", body[0], state.level.exit)
+	}
+	seen := map[Vec2]bool{}
+	for _, segment := range body {
+		if seen[segment] {
+			t.Errorf("body = %v, want no two segments on %v", body, segment)
+		}
+		seen[segment] = true
+	}
+}
+
+// newEndlessState starts an endless game on the given level
+func newEndlessState(t *testing.T, level string) State {
+	t.Helper()
+	config := DefaultConfig()
+	config.levels = testLevels(level)
+	config.mode = ModeEndless
+	state, err := NewState(1, config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return state
+}
+
+func TestEndlessFoodRegenerates(t *testing.T) {
+	state := newEndlessState(t, "########\n#SF...E#\n#......#\n########\n")
+	stepUntilMoved(t, &state, Vec2{x: 1, y: 0})
+	if len(state.level.foods) != 1 {
+		t.Fatalf("foods = %v after eating the only one, want a new one", state.level.foods)
+	}
+	food := state.level.foods[0].position
+	if state.level.walls[food.y][food.x] || food == state.level.exit || food == state.snakes[0].getHead() {
+		t.Errorf("new food at %v, want it on an empty cell", food)
+	}
+
+	// running along the top row crosses the exit without finishing the game
+	for i := 0; i < 1000 && state.snakes[0].getHead() != state.level.exit && state.status == StatusPlaying; i++ {
+		state.Step(Vec2{})
+	}
+	if state.snakes[0].getHead() != state.level.exit {
+		t.Fatalf("the snake never reached the exit, status %v", state.status)
+	}
+	if state.status != StatusPlaying {
+		t.Errorf("status = %v on the exit in endless mode, want playing", state.status)
+	}
+}
+
+func TestEndlessNoEmptyCell(t *testing.T) {
+	state := newEndlessState(t, "#####\n#SFE#\n#####\n")
+	stepUntilMoved(t, &state, Vec2{x: 1, y: 0})
+	if len(state.level.foods) != 0 {
+		t.Errorf("foods = %v, want none with no empty cell left", state.level.foods)
+	}
+	if state.status != StatusWon {
+		t.Errorf("status = %v with nowhere to put food, want won", state.status)
+	}
+}
+
+func TestSecondsLeft(t *testing.T) {
+	tests := []struct {
+		frames int
+		want   int
+	}{
+		{0, 0},
+		{1, 1},
+		{FPS - 1, 1},
+		{FPS, 1},
+		{FPS + 1, 2},
+		{10 * FPS, 10},
+	}
+	for _, test := range tests {
+		if got := secondsLeft(test.frames); got != test.want {
+			t.Errorf("secondsLeft(%d) = %d, want %d", test.frames, got, test.want)
+		}
+	}
+}
+
+func TestViewportNarrowLevel(t *testing.T) {
+	for _, solidEdges := range []bool{false, true} {
+		for x := 0; x < 6; x++ {
+			state := State{
+				level:     Level{width: 6, height: 30, solidEdges: solidEdges},
+				layout:    Layout{width: 100, height: 100, cellSize: 10},
+				snakes:    NewSlice(NewSnake(Vec2{x: x, y: 20}, 1)),
+				viewportX: 3,
+			}
+			updateViewport(&state)
+			if state.viewportX != 0 {
+				t.Errorf("solid edges %v, head at x %d: viewportX = %d, want 0 on a level narrower than the viewport", solidEdges, x, state.viewportX)
+			}
+			if state.viewportY == 0 {
+				t.Errorf("solid edges %v, head at y 20: viewportY = 0, want it following down the tall level", solidEdges)
+			}
+		}
+	}
+}
+
+func TestHeldDirectionQueuesOneTurn(t *testing.T) {
+	state := newTestState(t, "#######\n#.....#\n#.....#\n#.S...#\n#.....#\n#F...E#\n#######\n")
+	stepUntilMoved(t, &state, Vec2{x: 1, y: 0})
+	snake := &state.snakes[0]
+	up := NewSlice(UP_INPUT)
+
+	head := snake.getHead()
+	for i := 0; i < 100 && snake.getHead() == head; i++ {
+		state.update(up)
+		if snake.getHead() == head && len(snake.inputQueue) != 1 {
+			t.Fatalf("input queue = %v while up is held, want the one turn", snake.inputQueue)
+		}
+	}
+	if want := head.add(Vec2{x: 0, y: -1}); snake.getHead() != want {
+		t.Fatalf("head = %v, want %v after one turn up", snake.getHead(), want)
+	}
+
+	// still holding up once the snake is heading that way queues nothing
+	for i := 0; i < 5; i++ {
+		state.update(up)
+		if len(snake.inputQueue) != 0 {
+			t.Fatalf("input queue = %v while heading up with up held, want it empty", snake.inputQueue)
+		}
+	}
+}
+
+func TestCoiledSnakeReachesExit(t *testing.T) {
+	state := newTestState(t, "#######\n#SFFFF#\n#....F#\n#EFFFF#\n#######\n", "#####\n#SFE#\n#####\n")
+	turns := []struct {
+		direction Vec2
+		steps     int
+	}{
+		{Vec2{x: 1, y: 0}, 4},
+		{Vec2{x: 0, y: 1}, 2},
+		{Vec2{x: -1, y: 0}, 4},
+	}
+	for _, turn := range turns {
+		for i := 0; i < turn.steps; i++ {
+			direction := Vec2{}
+			if i == 0 {
+				direction = turn.direction
+			}
+			stepUntilMoved(t, &state, direction)
+
+			body := state.snakes[0].body
+			seen := map[Vec2]bool{}
+			for _, segment := range body {
+				if seen[segment] {
+					t.Fatalf("body = %v, want no two segments on %v", body, segment)
+				}
+				seen[segment] = true
+			}
+		}
+	}
+	if state.status != StatusLevelComplete {
+		t.Fatalf("status = %v with the head on %v, want level complete", state.status, state.snakes[0].getHead())
+	}
+	if len(state.snakes[0].body) != 10 {
+		t.Errorf("body = %v at the exit, want 10 segments after eating 9 foods", state.snakes[0].body)
+	}
+}
+
+func TestEdges(t *testing.T) {
+	tests := []struct {
+		name      string
+		level     string
+		wantHead  Vec2
+		wantLives int
+	}{
+		{"wrapping", "S.F...\n.....E\n", Vec2{x: 5, y: 0}, 3},
+		{"solid", ";wrap=false\nS.F...\n.....E\n", Vec2{x: 0, y: 0}, 2},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			state := newTestState(t, test.level)
+			state.Step(Vec2{x: -1, y: 0})
+			for i := 0; i < 100 && state.status == StatusPlaying && state.snakes[0].getHead() == (Vec2{}); i++ {
+				state.Step(Vec2{})
+			}
+			if head := state.snakes[0].getHead(); head != test.wantHead {
+				t.Errorf("head = %v after stepping off the left edge, want %v", head, test.wantHead)
+			}
+			if state.lives != test.wantLives {
+				t.Errorf("lives = %d after stepping off the left edge, want %d", state.lives, test.wantLives)
+			}
+		})
+	}
+}
+
+func TestFillLevel(t *testing.T) {
+	// the arrow lets the snake into the dead end on the right but not back
+	// out, so eating both foods leaves it covering every cell it can reach
+	state := newTestState(t, "######\n#S>FF#\n#E####\n######\n")
+	lives := state.lives
+	state.Step(Vec2{x: 1, y: 0})
+	for i := 0; i < 1000 && state.status == StatusPlaying; i++ {
+		state.Step(Vec2{})
+	}
+	if state.status != StatusWon || !state.perfect {
+		t.Errorf("status %v and perfect %v after filling the level, want won and perfect", state.status, state.perfect)
+	}
+	if state.lives != lives {
+		t.Errorf("lives = %d, want %d with nothing hit", state.lives, lives)
+	}
+	if len(state.snakes[0].body) != 3 {
+		t.Errorf("body = %v, want it over all 3 cells of the dead end", state.snakes[0].body)
+	}
+}
+
+func TestHeldDirectionsKeepMoving(t *testing.T) {
+	const (
+		up    = UP_INPUT
+		down  = 1 << 1
+		left  = 1 << 2
+		right = RIGHT_INPUT
+	)
+	tests := []struct {
+		name  string
+		input int
+		want  Vec2
+	}{
+		{"left and right", left | right, Vec2{x: 6, y: 4}},
+		{"up and down", up | down, Vec2{x: 6, y: 4}},
+		{"all four", up | down | left | right, Vec2{x: 6, y: 4}},
+		// two directions held along different axes zigzag between them
+		{"up and left", up | left, Vec2{x: 2, y: 2}},
+		{"down and right", down | right, Vec2{x: 4, y: 6}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			state := newTestState(t, "##########\n#........#\n#........#\n#........#\n#S.......#\n#........#\n#........#\n#........#\n#F......E#\n##########\n")
+			state.status = StatusPlaying
+			lives := state.lives
+			updateUntilMoved(t, &state, RIGHT_INPUT)
+			updateUntilMoved(t, &state, RIGHT_INPUT)
+			for i := 0; i < 3; i++ {
+				updateUntilMoved(t, &state, test.input)
+			}
+			if head := state.snakes[0].getHead(); head != test.want {
+				t.Errorf("head = %v, want %v", head, test.want)
+			}
+			if state.lives != lives {
+				t.Errorf("lives = %d, want %d", state.lives, lives)
+			}
+		})
+	}
+}
+
+func TestLockedExit(t *testing.T) {
+	state := newTestState(t, ";allfood=true\n######\n#SE.F#\n#....#\n######\n")
+	stepUntilMoved(t, &state, Vec2{x: 1, y: 0})
+	if state.snakes[0].getHead() != state.level.exit {
+		t.Fatalf("head = %v, want it on the exit", state.snakes[0].getHead())
+	}
+	if state.status != StatusPlaying {
+		t.Fatalf("status = %v on the exit with food left, want playing", state.status)
+	}
+
+	moves := []Vec2{{x: 1, y: 0}, {x: 1, y: 0}, {x: 0, y: 1}, {x: -1, y: 0}, {x: -1, y: 0}, {x: 0, y: -1}}
+	for _, direction := range moves {
+		stepUntilMoved(t, &state, direction)
+	}
+	if state.snakes[0].getHead() != state.level.exit {
+		t.Fatalf("head = %v, want it back on the exit", state.snakes[0].getHead())
+	}
+	if state.status != StatusWon {
+		t.Errorf("status = %v on the exit with every food eaten, want won", state.status)
+	}
+}
+
+func TestPractice(t *testing.T) {
+	config := DefaultConfig()
+	config.levels = testLevels("########\n#SFF..E#\n#......#\n########\n")
+	config.mode = ModePractice
+	state, err := NewState(1, config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lives := state.lives
+
+	stepUntilMoved(t, &state, Vec2{x: 1, y: 0})
+	stepUntilMoved(t, &state, Vec2{})
+	if len(state.level.foods) != 0 {
+		t.Fatalf("foods = %v, want both eaten", state.level.foods)
+	}
+	if state.score != 0 {
+		t.Errorf("score = %d after eating in practice, want 0", state.score)
+	}
+
+	// crashing into the top wall respawns the snake with the food still eaten
+	state.Step(Vec2{x: 0, y: -1})
+	for i := 0; i < 100 && state.deaths == 0; i++ {
+		state.Step(Vec2{})
+	}
+	if state.deaths != 1 || state.lives != lives || state.status != StatusPlaying {
+		t.Errorf("deaths %d, lives %d, status %v after a crash in practice, want 1, %d, playing", state.deaths, state.lives, state.status, lives)
+	}
+	if head := state.snakes[0].getHead(); head != state.level.entrance {
+		t.Errorf("head = %v after the crash, want it respawned at %v", head, state.level.entrance)
+	}
+	if len(state.level.foods) != 0 {
+		t.Errorf("foods = %v after the crash, want them left eaten", state.level.foods)
+	}
+}
+
+func TestNoclipCollision(t *testing.T) {
+	state := newTestState(t, "#######\n#S.#.G#\n#F...E#\n#######\n")
+	state.snakes[0].body = NewSlice(Vec2{x: 2, y: 2}, Vec2{x: 3, y: 2}, Vec2{x: 4, y: 2})
+	tests := []struct {
+		name       string
+		cell       Vec2
+		want       bool
+		wantNoclip bool
+	}{
+		{"empty", Vec2{x: 2, y: 1}, false, false},
+		{"wall", Vec2{x: 3, y: 1}, true, false},
+		{"own body", Vec2{x: 3, y: 2}, true, false},
+		{"ghost", Vec2{x: 5, y: 1}, true, true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			state.noclip = false
+			if got := state.snakes[0].checkCollision(&state, test.cell); got != test.want {
+				t.Errorf("checkCollision(%v) = %v, want %v", test.cell, got, test.want)
+			}
+			state.noclip = true
+			if got := state.snakes[0].checkCollision(&state, test.cell); got != test.wantNoclip {
+				t.Errorf("checkCollision(%v) = %v with noclip, want %v", test.cell, got, test.wantNoclip)
+			}
+		})
+	}
+}
+
+func TestNoclipPassesWalls(t *testing.T) {
+	state := newTestState(t, "#######\n#S.#..#\n#F...E#\n#######\n")
+	state.noclip = true
+	lives := state.lives
+	stepUntilMoved(t, &state, Vec2{x: 1, y: 0})
+	stepUntilMoved(t, &state, Vec2{})
+	if head := state.snakes[0].getHead(); head != (Vec2{x: 3, y: 1}) {
+		t.Errorf("head = %v, want it inside the wall at {3 1}", head)
+	}
+	if state.lives != lives {
+		t.Errorf("lives = %d, want %d with noclip on", state.lives, lives)
+	}
+	if !state.level.walls[1][3] {
+		t.Error("the wall was removed, want noclip to leave it")
+	}
+}
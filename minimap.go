@@ -0,0 +1,136 @@
+package main
+
+import (
+	"image"
+	"image/color"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/vector"
+)
+
+const (
+	MINIMAP_SIZE   = 120 // pixels along the longer side of the level
+	MINIMAP_MARGIN = 10  // pixels from the bottom right corner of the screen
+	MINIMAP_ALPHA  = 200 // opacity of the open cells, so the level shows through
+)
+
+// Minimap is a level's walls drawn at one pixel per cell, scaled up when it
+// is drawn to the screen. like WallLayer it's only redrawn when the walls or
+// their color change.
+type Minimap struct {
+	image   *ebiten.Image
+	levelID int
+	palette Palette
+}
+
+// NewMinimap draws the level's walls to a new image, one pixel per cell
+func NewMinimap(level *Level, palette Palette) *Minimap {
+	open := palette.background
+	open.A = MINIMAP_ALPHA
+
+	pixels := make([]byte, level.width*level.height*4)
+	for y := 0; y < level.height; y++ {
+		for x := 0; x < level.width; x++ {
+			c := open
+			if level.walls[y][x] {
+				c = palette.wall
+			}
+			i := (y*level.width + x) * 4
+			// WritePixels takes premultiplied alpha
+			pixels[i] = uint8(uint16(c.R) * uint16(c.A) / 255)
+			pixels[i+1] = uint8(uint16(c.G) * uint16(c.A) / 255)
+			pixels[i+2] = uint8(uint16(c.B) * uint16(c.A) / 255)
+			pixels[i+3] = c.A
+		}
+	}
+
+	minimap := &Minimap{
+		image:   ebiten.NewImage(level.width, level.height),
+		levelID: level.id,
+		palette: palette,
+	}
+	minimap.image.WritePixels(pixels)
+	return minimap
+}
+
+// minimap returns the state's minimap, drawing a new one if the level or
+// palette has changed since the last one was drawn
+func (state *State) minimap() *Minimap {
+	minimap := state.minimapCache
+	if minimap != nil && minimap.levelID == state.level.id && minimap.palette == state.config.palette {
+		return minimap
+	}
+	if minimap != nil {
+		minimap.image.Deallocate()
+	}
+	state.minimapCache = NewMinimap(&state.level, state.config.palette)
+	return state.minimapCache
+}
+
+// needsMinimap reports whether the level is too big to be seen whole
+func needsMinimap(state *State) bool {
+	return state.level.width > state.layout.viewportWidth() || state.level.height > state.layout.viewportHeight()
+}
+
+// drawMinimap draws the whole level scaled down in the bottom right corner of
+// the screen, with dots for the food, the exit, and each snake's head, and an
+// outline around the part of the level in the viewport. it's skipped for
+// levels that fit on screen, or when hidden with N.
+func (game *Game) drawMinimap(screen *ebiten.Image) {
+	state := &game.state
+	if game.hideMinimap || !needsMinimap(state) {
+		return
+	}
+	palette := state.config.palette
+
+	scale := float32(MINIMAP_SIZE) / float32(state.level.width)
+	if state.level.height > state.level.width {
+		scale = float32(MINIMAP_SIZE) / float32(state.level.height)
+	}
+	width := int(float32(state.level.width) * scale)
+	height := int(float32(state.level.height) * scale)
+	left := game.layout.width - width - MINIMAP_MARGIN
+	top := game.layout.height - height - MINIMAP_MARGIN
+	area := screen.SubImage(image.Rect(left, top, left+width, top+height)).(*ebiten.Image)
+
+	op := &ebiten.DrawImageOptions{}
+	op.GeoM.Scale(float64(scale), float64(scale))
+	op.GeoM.Translate(float64(left), float64(top))
+	area.DrawImage(state.minimap().image, op)
+
+	// dots are at least two pixels across so they show on the biggest levels
+	dotSize := scale
+	if dotSize < 2 {
+		dotSize = 2
+	}
+	dot := func(p Vec2, c color.Color) {
+		vector.DrawFilledRect(area, float32(left)+float32(p.x)*scale, float32(top)+float32(p.y)*scale, dotSize, dotSize, c, false)
+	}
+	for _, food := range state.level.foods {
+		if food.kind != FoodPower {
+			dot(food.position, palette.food)
+		}
+	}
+	if state.config.mode != ModeEndless {
+		dot(state.level.exit, palette.menuSelection)
+	}
+	for _, snake := range state.snakes {
+		c := palette.snake
+		if snake.player == 1 {
+			c = palette.rival
+		}
+		dot(snake.getHead(), c)
+	}
+
+	// the viewport wraps around the level's edges, so its outline is drawn
+	// again one level over in each direction and clipped to the minimap
+	viewportWidth := float32(state.layout.viewportWidth()) * scale
+	viewportHeight := float32(state.layout.viewportHeight()) * scale
+	for _, dy := range [3]int{-1, 0, 1} {
+		for _, dx := range [3]int{-1, 0, 1} {
+			x := float32(left) + float32(state.viewportX+dx*state.level.width)*scale
+			y := float32(top) + float32(state.viewportY+dy*state.level.height)*scale
+			vector.StrokeRect(area, x, y, viewportWidth, viewportHeight, 1, palette.menuItem, false)
+		}
+	}
+}
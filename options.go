@@ -0,0 +1,200 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"strconv"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/inpututil"
+	"github.com/hajimehoshi/ebiten/v2/text/v2"
+)
+
+// MAX_STARTING_LIVES is the most lives the options screen lets a game start
+// with, before the difficulty adds or takes any away
+const MAX_STARTING_LIVES = 9
+
+// Option is a setting that can be changed on the options screen
+type Option int
+
+const (
+	OptionSound Option = iota
+	OptionDifficulty
+	OptionPalette
+	OptionLives
+	OptionSnakeStyle
+)
+
+// options lists every option in the order they are shown and saved
+var options = [5]Option{OptionSound, OptionDifficulty, OptionPalette, OptionLives, OptionSnakeStyle}
+
+func (option Option) String() string {
+	switch option {
+	case OptionSound:
+		return "sound"
+	case OptionDifficulty:
+		return "difficulty"
+	case OptionPalette:
+		return "palette"
+	case OptionLives:
+		return "lives"
+	case OptionSnakeStyle:
+		return "snake"
+	}
+	return "unknown"
+}
+
+// value returns the option's current setting in config as it's shown on the
+// options screen and written to the options file
+func (option Option) value(config Config) string {
+	switch option {
+	case OptionSound:
+		if config.soundEnabled {
+			return "on"
+		}
+		return "off"
+	case OptionDifficulty:
+		return config.difficulty.String()
+	case OptionPalette:
+		return config.palette.name
+	case OptionLives:
+		return strconv.Itoa(config.startingLives)
+	case OptionSnakeStyle:
+		return config.snakeStyle.String()
+	}
+	return ""
+}
+
+// change returns config with the option stepped forward one setting when
+// delta is positive, or back one when it's negative, wrapping at either end
+func (option Option) change(config Config, delta int) Config {
+	switch option {
+	case OptionSound:
+		config.soundEnabled = !config.soundEnabled
+	case OptionDifficulty:
+		config.difficulty = Difficulty(mod(int(config.difficulty)+delta, int(DifficultyHard)+1))
+	case OptionPalette:
+		for i, palette := range palettes {
+			if palette.name == config.palette.name {
+				config.palette = palettes[mod(i+delta, len(palettes))]
+				return config
+			}
+		}
+		config.palette = palettes[0]
+	case OptionLives:
+		config.startingLives = mod(config.startingLives-1+delta, MAX_STARTING_LIVES) + 1
+	case OptionSnakeStyle:
+		config.snakeStyle = SnakeStyle(mod(int(config.snakeStyle)+delta, int(SnakeStyleRounded)+1))
+	}
+	return config
+}
+
+// set returns config with the option set to value, in the format written by
+// value
+func (option Option) set(config Config, value string) (Config, error) {
+	switch option {
+	case OptionSound:
+		switch value {
+		case "on":
+			config.soundEnabled = true
+		case "off":
+			config.soundEnabled = false
+		default:
+			return config, fmt.Errorf("expected on or off, got %q", value)
+		}
+		return config, nil
+	case OptionDifficulty:
+		for difficulty := DifficultyEasy; difficulty <= DifficultyHard; difficulty++ {
+			if difficulty.String() == value {
+				config.difficulty = difficulty
+				return config, nil
+			}
+		}
+		return config, fmt.Errorf("unknown difficulty %q", value)
+	case OptionPalette:
+		for _, palette := range palettes {
+			if palette.name == value {
+				config.palette = palette
+				return config, nil
+			}
+		}
+		return config, fmt.Errorf("unknown palette %q", value)
+	case OptionLives:
+		lives, err := strconv.Atoi(value)
+		if err != nil || lives < 1 || lives > MAX_STARTING_LIVES {
+			return config, fmt.Errorf("expected lives from 1 to %d, got %q", MAX_STARTING_LIVES, value)
+		}
+		config.startingLives = lives
+		return config, nil
+	case OptionSnakeStyle:
+		style, err := parseSnakeStyle(value)
+		if err != nil {
+			return config, err
+		}
+		config.snakeStyle = style
+		return config, nil
+	}
+	return config, fmt.Errorf("unknown option %q", option)
+}
+
+// updateOptionsState moves the selection with up and down and changes the
+// selected option with left and right. Esc, Enter, or start saves the options
+// and returns to the menu. changes are made to the game's config, so they take
+// effect from the next game started.
+func (game *Game) updateOptionsState() {
+	up := isMenuDirectionPressed(game.config.keys, Vec2{x: 0, y: -1})
+	if up && game.optionsSelection > 0 {
+		game.optionsSelection--
+	}
+	down := isMenuDirectionPressed(game.config.keys, Vec2{x: 0, y: 1})
+	if down && game.optionsSelection < len(options)-1 {
+		game.optionsSelection++
+	}
+
+	delta := 0
+	if isMenuDirectionPressed(game.config.keys, Vec2{x: -1, y: 0}) {
+		delta -= 1
+	}
+	if isMenuDirectionPressed(game.config.keys, Vec2{x: 1, y: 0}) {
+		delta += 1
+	}
+	if delta != 0 {
+		option := options[game.optionsSelection]
+		game.config = option.change(game.config, delta)
+		// sound is the one option heard on the menu, so it's applied now
+		if option == OptionSound {
+			sounds.muted = !game.config.soundEnabled
+		}
+	}
+
+	if inpututil.IsKeyJustPressed(ebiten.KeyEscape) || inpututil.IsKeyJustPressed(ebiten.KeyEnter) || inpututil.IsKeyJustPressed(game.config.keys.key(ActionStart)) || isGamepadButtonJustPressed(GAMEPAD_START_BUTTON) {
+		if err := saveConfigFile(game.config); err != nil {
+			log.Printf("saving config: %v", err)
+		}
+		game.state.status = StatusStarted
+	}
+}
+
+// drawOptions draws each option with its current setting, the selected one
+// highlighted, and how to change them
+func (game *Game) drawOptions(screen *ebiten.Image) {
+	drawCenteredText(screen, "options", &game.font.regular, 80)
+
+	for i, option := range options {
+		item := option.String() + ": " + option.value(game.config)
+		itemColor := game.config.palette.menuItem
+		if i == game.optionsSelection {
+			item = "< " + item + " >"
+			itemColor = game.config.palette.menuSelection
+		}
+
+		width, _ := text.Measure(item, &game.font.small, 0)
+		op := &text.DrawOptions{}
+		op.GeoM.Translate((float64(game.layout.width)-width)/2, float64(160+i*30))
+		op.ColorScale.ScaleWithColor(itemColor)
+		text.Draw(screen, item, &game.font.small, op)
+	}
+
+	drawCenteredText(screen, "left and right change the selected option", &game.font.tiny, float64(game.layout.height)-65)
+	drawCenteredText(screen, "press ESC to save and return to the menu", &game.font.tiny, float64(game.layout.height)-45)
+}
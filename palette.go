@@ -0,0 +1,147 @@
+package main
+
+import (
+	"fmt"
+	"image/color"
+	"strconv"
+	"strings"
+)
+
+// Palette holds every color the game draws with, so the look can be swapped
+// as a whole
+type Palette struct {
+	name       string
+	background color.RGBA
+	wall       color.RGBA
+	food       color.RGBA
+	// valuableFood is blended into food the more points a food is worth
+	valuableFood color.RGBA
+	// shrinkFood and bonusFood are used for the food kinds other than plain
+	// food and power pellets
+	shrinkFood color.RGBA
+	bonusFood  color.RGBA
+	exit       color.RGBA
+	portal     color.RGBA
+	arrow      color.RGBA
+	snake      color.RGBA
+	// rival is used for the second player's snake in versus mode
+	rival color.RGBA
+	// powerUp is used for the snake while a power-up is active
+	powerUp color.RGBA
+	// deadHead is used for the snake's head once the game is lost
+	deadHead color.RGBA
+	ghost    color.RGBA
+	// frightenedGhost is used for ghosts while they can be eaten
+	frightenedGhost color.RGBA
+	// overlay is drawn over the level behind the pause and end messages
+	overlay       color.RGBA
+	menuItem      color.RGBA
+	menuSelection color.RGBA
+}
+
+// DefaultPalette returns the colors the game ships with
+func DefaultPalette() Palette {
+	return Palette{
+		name:            "classic",
+		background:      color.RGBA{0, 0, 0, 255},       // black
+		wall:            color.RGBA{100, 100, 100, 255}, // gray
+		food:            color.RGBA{255, 0, 0, 255},     // red
+		valuableFood:    color.RGBA{255, 215, 0, 255},   // gold
+		shrinkFood:      color.RGBA{160, 82, 45, 255},   // sienna
+		bonusFood:       color.RGBA{255, 140, 0, 255},   // dark orange
+		exit:            color.RGBA{0, 0, 0, 255},       // black
+		portal:          color.RGBA{160, 32, 240, 255},  // purple
+		arrow:           color.RGBA{255, 255, 255, 255}, // white
+		snake:           color.RGBA{0, 255, 0, 255},     // green
+		rival:           color.RGBA{0, 255, 255, 255},   // cyan
+		powerUp:         color.RGBA{0, 0, 255, 255},     // blue
+		deadHead:        color.RGBA{255, 165, 0, 120},   // orange
+		ghost:           color.RGBA{255, 105, 180, 255}, // pink
+		frightenedGhost: color.RGBA{200, 200, 255, 255}, // pale blue
+		overlay:         color.RGBA{0, 0, 0, 128},       // translucent black
+		menuItem:        color.RGBA{150, 150, 150, 255}, // light gray
+		menuSelection:   color.RGBA{255, 255, 0, 255},   // yellow
+	}
+}
+
+// ColorblindPalette returns colors from the Okabe-Ito set, which stay
+// distinguishable for red-green colorblind players
+func ColorblindPalette() Palette {
+	return Palette{
+		name:            "colorblind",
+		background:      color.RGBA{0, 0, 0, 255},       // black
+		wall:            color.RGBA{100, 100, 100, 255}, // gray
+		food:            color.RGBA{213, 94, 0, 255},    // vermillion
+		valuableFood:    color.RGBA{240, 228, 66, 255},  // yellow
+		shrinkFood:      color.RGBA{0, 158, 115, 255},   // bluish green
+		bonusFood:       color.RGBA{230, 159, 0, 255},   // orange
+		exit:            color.RGBA{0, 0, 0, 255},       // black
+		portal:          color.RGBA{0, 114, 178, 255},   // blue
+		arrow:           color.RGBA{255, 255, 255, 255}, // white
+		snake:           color.RGBA{86, 180, 233, 255},  // sky blue
+		rival:           color.RGBA{230, 159, 0, 255},   // orange
+		powerUp:         color.RGBA{255, 255, 255, 255}, // white
+		deadHead:        color.RGBA{230, 159, 0, 120},   // orange
+		ghost:           color.RGBA{204, 121, 167, 255}, // reddish purple
+		frightenedGhost: color.RGBA{0, 158, 115, 255},   // bluish green
+		overlay:         color.RGBA{0, 0, 0, 128},       // translucent black
+		menuItem:        color.RGBA{150, 150, 150, 255}, // light gray
+		menuSelection:   color.RGBA{240, 228, 66, 255},  // yellow
+	}
+}
+
+// HighContrastPalette returns bright colors on black for low vision players
+func HighContrastPalette() Palette {
+	return Palette{
+		name:            "high contrast",
+		background:      color.RGBA{0, 0, 0, 255},       // black
+		wall:            color.RGBA{255, 255, 255, 255}, // white
+		food:            color.RGBA{255, 255, 0, 255},   // yellow
+		valuableFood:    color.RGBA{255, 128, 0, 255},   // orange
+		shrinkFood:      color.RGBA{128, 255, 0, 255},   // chartreuse
+		bonusFood:       color.RGBA{255, 0, 128, 255},   // rose
+		exit:            color.RGBA{0, 0, 0, 255},       // black
+		portal:          color.RGBA{0, 128, 255, 255},   // azure
+		arrow:           color.RGBA{255, 255, 0, 255},   // yellow
+		snake:           color.RGBA{0, 255, 255, 255},   // cyan
+		rival:           color.RGBA{0, 255, 0, 255},     // green
+		powerUp:         color.RGBA{255, 0, 255, 255},   // magenta
+		deadHead:        color.RGBA{255, 0, 0, 255},     // red
+		ghost:           color.RGBA{255, 0, 0, 255},     // red
+		frightenedGhost: color.RGBA{0, 0, 255, 255},     // blue
+		overlay:         color.RGBA{0, 0, 0, 192},       // translucent black
+		menuItem:        color.RGBA{200, 200, 200, 255}, // light gray
+		menuSelection:   color.RGBA{255, 255, 0, 255},   // yellow
+	}
+}
+
+// palettes lists the presets in the order they are cycled through
+var palettes = Slice[Palette]{DefaultPalette(), ColorblindPalette(), HighContrastPalette()}
+
+// nextPalette returns the preset after the one with current's name, wrapping
+// back to the first
+func nextPalette(current Palette) Palette {
+	for i, palette := range palettes {
+		if palette.name == current.name {
+			return palettes[(i+1)%len(palettes)]
+		}
+	}
+	return palettes[0]
+}
+
+// parseHexColor reads an opaque color written as "#rrggbb" or "#rgb", with or
+// without the '#'
+func parseHexColor(value string) (color.RGBA, error) {
+	hex := strings.TrimPrefix(strings.TrimSpace(value), "#")
+	if len(hex) == 3 {
+		hex = string([]byte{hex[0], hex[0], hex[1], hex[1], hex[2], hex[2]})
+	}
+	if len(hex) != 6 {
+		return color.RGBA{}, fmt.Errorf("bad color %q: expected #rrggbb", value)
+	}
+	rgb, err := strconv.ParseUint(hex, 16, 32)
+	if err != nil {
+		return color.RGBA{}, fmt.Errorf("bad color %q: expected #rrggbb", value)
+	}
+	return color.RGBA{R: uint8(rgb >> 16), G: uint8(rgb >> 8), B: uint8(rgb), A: 255}, nil
+}
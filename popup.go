@@ -0,0 +1,61 @@
+package main
+
+import (
+	"strconv"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/text/v2"
+)
+
+const POPUP_FRAMES = 45 // how long a popup rises and fades for
+
+// Popup is the number of points scored floating up from where they were
+// earned
+type Popup struct {
+	position Vec2
+	text     string
+	// framesLeft counts down from POPUP_FRAMES to 0, when the popup is removed
+	framesLeft int
+}
+
+func NewPopup(position Vec2, points int) Popup {
+	return Popup{
+		position:   position,
+		text:       "+" + strconv.Itoa(points),
+		framesLeft: POPUP_FRAMES,
+	}
+}
+
+// updatePopups ages every popup by a frame and removes the ones that have
+// finished
+func updatePopups(state *State) {
+	for i := len(state.popups) - 1; i >= 0; i-- {
+		state.popups[i].framesLeft -= 1
+		if state.popups[i].framesLeft <= 0 {
+			state.popups = state.popups.removeAt(i)
+		}
+	}
+}
+
+// drawPopups draws each popup centered over the cell it was earned in, rising
+// by a cell and fading out over its lifetime
+func drawPopups(screen *ebiten.Image, state *State, face text.Face) {
+	size := float64(state.layout.cellSize)
+	for _, popup := range state.popups {
+		if !isVisible(state, popup.position) {
+			continue
+		}
+		remaining := float64(popup.framesLeft) / POPUP_FRAMES
+		offset := viewportOffset(state, popup.position)
+		width, height := text.Measure(popup.text, face, 0)
+
+		op := &text.DrawOptions{}
+		op.GeoM.Translate(
+			(float64(offset.x)+0.5)*size-width/2,
+			(float64(offset.y)+0.5)*size-height/2-size*(1-remaining),
+		)
+		op.ColorScale.ScaleWithColor(state.config.palette.valuableFood)
+		op.ColorScale.ScaleAlpha(float32(remaining))
+		text.Draw(screen, popup.text, face, op)
+	}
+}
@@ -0,0 +1,35 @@
+package main
+
+import "github.com/hajimehoshi/ebiten/v2/inpututil"
+
+const (
+	KEY_REPEAT_DELAY    = 24 // ticks a menu key is held before it starts repeating
+	KEY_REPEAT_INTERVAL = 6  // ticks between repeats once it has
+)
+
+// isRepeat reports whether a key or button that has been held for the given
+// number of ticks acts on this tick. it acts as soon as it's pressed, then
+// again every KEY_REPEAT_INTERVAL ticks once it has been held for
+// KEY_REPEAT_DELAY, so holding it scrolls through a menu at a steady rate
+// rather than once a frame.
+func isRepeat(duration int) bool {
+	if duration == 1 {
+		return true
+	}
+	if duration <= KEY_REPEAT_DELAY {
+		return false
+	}
+	return (duration-KEY_REPEAT_DELAY)%KEY_REPEAT_INTERVAL == 0
+}
+
+// isMenuDirectionPressed reports whether the key bound to the given direction,
+// or a gamepad's D-pad, moves a menu selection that way this tick, repeating
+// while it's held
+func isMenuDirectionPressed(keys KeyBindings, direction Vec2) bool {
+	for i, d := range directions {
+		if d == direction && isRepeat(inpututil.KeyPressDuration(keys.directionKeys()[i])) {
+			return true
+		}
+	}
+	return isGamepadDirectionRepeated(direction)
+}
@@ -0,0 +1,21 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestIsRepeat(t *testing.T) {
+	// the ticks a key held for 40 ticks acts on: when it's pressed, then
+	// every KEY_REPEAT_INTERVAL once it's been held for KEY_REPEAT_DELAY
+	var got []int
+	for duration := 0; duration <= 40; duration++ {
+		if isRepeat(duration) {
+			got = append(got, duration)
+		}
+	}
+	want := []int{1, 30, 36}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("acted on ticks %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,195 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// Recording is the input for every frame of one game, along with the settings
+// needed to start that game again. replaying it with the same seed plays the
+// game out exactly as it was recorded.
+type Recording struct {
+	level           int
+	seed            int
+	generate        bool
+	mode            Mode
+	difficulty      Difficulty
+	startingLives   int
+	suddenDeathTime int
+	// levelsDir is the directory given with -levels, or empty for the built in
+	// levels
+	levelsDir string
+	// frames holds an input mask per snake for each frame played, as returned
+	// by readInput
+	frames Slice[Slice[int]]
+}
+
+// NewRecording creates an empty Recording of a game starting at the given
+// level with the given configuration
+func NewRecording(level int, config Config) Recording {
+	return Recording{
+		level:      level,
+		seed:       config.seed,
+		generate:   config.generate,
+		mode:       config.mode,
+		difficulty: config.difficulty,
+		// lives are recorded before the difficulty changes them, since
+		// NewState applies it again on replay
+		startingLives:   config.startingLives,
+		suddenDeathTime: config.suddenDeathTime,
+		levelsDir:       config.levelsDir,
+		frames:          NewSlice[Slice[int]](),
+	}
+}
+
+// config returns the given configuration with the recorded game's settings
+// applied on top
+func (recording *Recording) config(config Config) Config {
+	config.seed = recording.seed
+	config.generate = recording.generate
+	config.mode = recording.mode
+	config.difficulty = recording.difficulty
+	config.startingLives = recording.startingLives
+	config.suddenDeathTime = recording.suddenDeathTime
+	config.levelsDir = recording.levelsDir
+	config.levels = embeddedLevels()
+	if recording.levelsDir != "" {
+		config.levels = os.DirFS(recording.levelsDir)
+	}
+	return config
+}
+
+// String formats the recording like a level file: ";key=value" metadata lines
+// followed by one line of space separated input masks per frame
+func (recording *Recording) String() string {
+	var builder strings.Builder
+	fmt.Fprintf(&builder, ";level=%d\n", recording.level)
+	fmt.Fprintf(&builder, ";seed=%d\n", recording.seed)
+	fmt.Fprintf(&builder, ";generate=%t\n", recording.generate)
+	fmt.Fprintf(&builder, ";mode=%d\n", recording.mode)
+	fmt.Fprintf(&builder, ";difficulty=%d\n", recording.difficulty)
+	fmt.Fprintf(&builder, ";lives=%d\n", recording.startingLives)
+	fmt.Fprintf(&builder, ";suddendeath=%d\n", recording.suddenDeathTime)
+	if recording.levelsDir != "" {
+		fmt.Fprintf(&builder, ";levels=%s\n", recording.levelsDir)
+	}
+	for _, frame := range recording.frames {
+		for i, mask := range frame {
+			if i > 0 {
+				builder.WriteByte(' ')
+			}
+			builder.WriteString(strconv.Itoa(mask))
+		}
+		builder.WriteByte('\n')
+	}
+	return builder.String()
+}
+
+// parseRecording reads a recording in the format written by String
+func parseRecording(content string) (Recording, error) {
+	// recordings made before difficulties, lives, and sudden death were
+	// recorded played with the defaults
+	recording := Recording{
+		difficulty:    DifficultyNormal,
+		startingLives: DefaultConfig().startingLives,
+		frames:        NewSlice[Slice[int]](),
+	}
+	lines := strings.Split(strings.TrimSpace(content), "\n")
+
+	for len(lines) > 0 && strings.HasPrefix(lines[0], ";") {
+		key, value, _ := strings.Cut(strings.TrimSpace(lines[0][1:]), "=")
+		var err error
+		switch key {
+		case "level":
+			recording.level, err = strconv.Atoi(value)
+		case "seed":
+			recording.seed, err = strconv.Atoi(value)
+		case "generate":
+			recording.generate, err = strconv.ParseBool(value)
+		case "mode":
+			var mode int
+			mode, err = strconv.Atoi(value)
+			recording.mode = Mode(mode)
+		case "difficulty":
+			var difficulty int
+			difficulty, err = strconv.Atoi(value)
+			recording.difficulty = Difficulty(difficulty)
+		case "lives":
+			recording.startingLives, err = strconv.Atoi(value)
+		case "suddendeath":
+			recording.suddenDeathTime, err = strconv.Atoi(value)
+		case "levels":
+			recording.levelsDir = value
+		}
+		if err != nil {
+			return Recording{}, fmt.Errorf("invalid recording: bad %s %q", key, value)
+		}
+		lines = lines[1:]
+	}
+
+	for i, line := range lines {
+		if line == "" {
+			continue
+		}
+		frame := NewSlice[int]()
+		for _, field := range strings.Fields(line) {
+			mask, err := strconv.Atoi(field)
+			if err != nil {
+				return Recording{}, fmt.Errorf("invalid recording: bad input %q on frame %d", field, i+1)
+			}
+			frame = append(frame, mask)
+		}
+		recording.frames = append(recording.frames, frame)
+	}
+	return recording, nil
+}
+
+// loadRecording reads a recording from the given file
+func loadRecording(filename string) (Recording, error) {
+	content, err := os.ReadFile(filename)
+	if err != nil {
+		return Recording{}, fmt.Errorf("loading recording: %w", err)
+	}
+	return parseRecording(string(content))
+}
+
+// saveRecording writes the current recording to the file given with -record,
+// if there is one. a failed save is logged rather than interrupting the game.
+func (game *Game) saveRecording() {
+	if game.recording == nil || game.recordFile == "" {
+		return
+	}
+	if err := os.WriteFile(game.recordFile, []byte(game.recording.String()), 0o644); err != nil {
+		log.Printf("saving recording: %v", err)
+	}
+}
+
+// nextInput returns the input for the current frame, read from the replay
+// when one is playing and from the keyboard, gamepads, swipes, and the
+// on-screen d-pad otherwise, with the autopilot steering player one when it's
+// on. live input is recorded when -record was given. once a replay runs out
+// of frames no more input is given.
+func (game *Game) nextInput() Slice[int] {
+	if game.replay != nil {
+		if game.replayFrame >= len(game.replay.frames) {
+			return NewSlice[int]()
+		}
+		input := game.replay.frames[game.replayFrame]
+		game.replayFrame++
+		return input
+	}
+
+	input := readInput(&game.state)
+	input[0] |= game.swipe.update()
+	input[0] |= game.dpad.update(game.layout.width, game.layout.height)
+	if game.autopilot {
+		input[0] = game.autopilotInput() | input[0]&NOCLIP_INPUT
+	}
+	if game.recording != nil {
+		game.recording.frames = append(game.recording.frames, input)
+	}
+	return input
+}
@@ -0,0 +1,75 @@
+package main
+
+import "testing"
+
+func TestRecordingRoundTrip(t *testing.T) {
+	config := DefaultConfig()
+	config.seed = 42
+	config.difficulty = DifficultyHard
+	config.startingLives = 7
+	config.suddenDeathTime = 600
+	config.levelsDir = "my levels"
+	recording := NewRecording(3, config)
+	recording.frames = append(recording.frames, NewSlice(1, 2), NewSlice(0))
+
+	parsed, err := parseRecording(recording.String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if parsed.String() != recording.String() {
+		t.Errorf("parsed recording = %q, want %q", parsed.String(), recording.String())
+	}
+
+	got := parsed.config(DefaultConfig())
+	if got.seed != 42 || got.difficulty != DifficultyHard {
+		t.Errorf("seed %d and difficulty %v, want 42 and hard", got.seed, got.difficulty)
+	}
+	if got.startingLives != 7 || got.suddenDeathTime != 600 {
+		t.Errorf("lives %d and sudden death %d, want 7 and 600", got.startingLives, got.suddenDeathTime)
+	}
+	if got.levelsDir != "my levels" {
+		t.Errorf("levels directory = %q, want %q", got.levelsDir, "my levels")
+	}
+}
+
+func TestOldRecordingUsesDefaults(t *testing.T) {
+	recording, err := parseRecording(";level=1\n;seed=5\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	config := DefaultConfig()
+	config.startingLives = 9
+	config.suddenDeathTime = 100
+	config.levelsDir = "elsewhere"
+	got := recording.config(config)
+	if got.startingLives != DefaultConfig().startingLives || got.suddenDeathTime != 0 || got.levelsDir != "" {
+		t.Errorf("lives %d, sudden death %d, levels %q, want the defaults", got.startingLives, got.suddenDeathTime, got.levelsDir)
+	}
+}
+
+func TestReplayNoclip(t *testing.T) {
+	level := "######\n#S#F.#\n#...E#\n######\n"
+	frames := NewSlice(NewSlice(RIGHT_INPUT | NOCLIP_INPUT))
+	for i := 0; i < 30; i++ {
+		frames = append(frames, NewSlice(0))
+	}
+	recording := Recording{level: 1, frames: frames}
+	replayed, err := parseRecording(recording.String())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, run := range []Recording{recording, replayed} {
+		state := newTestState(t, level)
+		state.status = StatusPlaying
+		for _, frame := range run.frames {
+			state.update(frame)
+		}
+		if !state.noclip {
+			t.Error("noclip wasn't turned on by the input")
+		}
+		if head := state.snakes[0].getHead(); head.x < 2 || state.lives != DefaultConfig().startingLives {
+			t.Errorf("head %v with %d lives, want the snake through the wall without a crash", head, state.lives)
+		}
+	}
+}
@@ -0,0 +1,89 @@
+package main
+
+const (
+	REWIND_HISTORY = 5 // moves a rewind goes back
+	REWIND_CHARGES = 3 // rewinds the player starts each game with
+	// REWIND_INPUT is the bit set in an input mask, past DIG_INPUT, while
+	// player one holds the rewind key
+	REWIND_INPUT = DIG_INPUT << 1
+)
+
+// takeSnapshot adds a copy of the state from before player one's snake takes a
+// step to the rewind history, dropping the oldest once there are
+// REWIND_HISTORY. versus games can't be rewound, so nothing is kept for them.
+func takeSnapshot(state *State) {
+	if state.config.mode == ModeVersus {
+		return
+	}
+	snapshot := state.clone()
+	snapshot.history = nil
+	if len(state.history) >= REWIND_HISTORY {
+		state.history = state.history.removeAt(0)
+	}
+	state.history = append(state.history, snapshot)
+}
+
+// clone returns a copy of the state that shares none of the slices play changes
+// in place, so changes to one don't show up in the other. the level's maps are
+// only changed by the editor, and are shared.
+func (state *State) clone() State {
+	clone := *state
+	clone.level.walls = make(Slice[Slice[bool]], len(state.level.walls))
+	for y, row := range state.level.walls {
+		clone.level.walls[y] = append(NewSlice[bool](), row...)
+	}
+	clone.level.foods = append(NewSlice[Food](), state.level.foods...)
+	clone.level.digPickups = append(NewSlice[Vec2](), state.level.digPickups...)
+	clone.level.slowPickups = append(NewSlice[Vec2](), state.level.slowPickups...)
+	clone.level.gates = append(NewSlice[Gate](), state.level.gates...)
+	clone.level.ghosts = append(NewSlice[Ghost](), state.level.ghosts...)
+	clone.snakes = append(NewSlice[Snake](), state.snakes...)
+	for i, snake := range state.snakes {
+		clone.snakes[i].body = append(NewSlice[Vec2](), snake.body...)
+		clone.snakes[i].trail = append(NewSlice[Vec2](), snake.trail...)
+		clone.snakes[i].inputQueue = append(NewSlice[Vec2](), snake.inputQueue...)
+	}
+	clone.popups = append(NewSlice[Popup](), state.popups...)
+	clone.run = append(NewSlice[Vec2](), state.run...)
+	clone.earned = append(NewSlice[Achievement](), state.earned...)
+	clone.pendingSounds = append(NewSlice[Sound](), state.pendingSounds...)
+	return clone
+}
+
+// updateRewind rewinds when the rewind key goes down, as long as there is a
+// charge left and history to go back through. the whole game goes back to how
+// it was up to REWIND_HISTORY moves ago, so eaten food and pickups come back,
+// dug walls fill in again, and a life lost since then is given back, which is
+// what makes a rewind useful after a crash. the snake keeps heading the way it
+// was then with any queued turns dropped, so the player has those moves to
+// choose another way.
+func updateRewind(state *State, input Slice[int]) {
+	pressed := len(input) > 0 && input[0]&REWIND_INPUT != 0
+	// the key has to be let go before it rewinds again, so holding it down
+	// spends one charge rather than all of them
+	justPressed := pressed && !state.rewindHeld
+	state.rewindHeld = pressed
+	if !justPressed || state.rewindCharges == 0 || len(state.history) == 0 {
+		return
+	}
+
+	snapshot := state.history[0]
+	// the rewind itself isn't undone, and neither are the frames played, the
+	// achievements and sounds Game hasn't collected yet, or anything outside
+	// of play
+	snapshot.history = nil
+	snapshot.rewindCharges = state.rewindCharges - 1
+	snapshot.rewindHeld = state.rewindHeld
+	snapshot.frame = state.frame
+	snapshot.earned = state.earned
+	snapshot.pendingSounds = state.pendingSounds
+	snapshot.rng = state.rng
+	snapshot.config = state.config
+	snapshot.layout = state.layout
+	snapshot.wallLayer = state.wallLayer
+	snapshot.minimapCache = state.minimapCache
+	snapshot.snakes[0].inputQueue = NewSlice[Vec2]()
+	*state = snapshot
+	// walls dug or closed since the snapshot are back as they were
+	state.invalidateWalls()
+}
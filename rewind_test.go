@@ -0,0 +1,76 @@
+package main
+
+import "testing"
+
+// UP_INPUT and RIGHT_INPUT are the input masks for holding up and right
+const (
+	UP_INPUT    = 1 << 0
+	RIGHT_INPUT = 1 << 3
+)
+
+// updateUntilMoved updates the state with the given input until player one's
+// snake has taken a step
+func updateUntilMoved(t *testing.T, state *State, input int) {
+	t.Helper()
+	head := state.snakes[0].getHead()
+	for i := 0; i < 100 && state.snakes[0].getHead() == head; i++ {
+		state.update(NewSlice(input))
+	}
+	if state.snakes[0].getHead() == head {
+		t.Fatal("the snake never moved")
+	}
+}
+
+// rewind presses and lets go of the rewind key
+func rewind(state *State) {
+	state.update(NewSlice(REWIND_INPUT))
+	state.update(NewSlice(0))
+}
+
+func TestRewindRestoresEverything(t *testing.T) {
+	state := newTestState(t, "#######\n#SPD#F#\n#...E.#\n#######\n")
+	state.status = StatusPlaying
+
+	updateUntilMoved(t, &state, RIGHT_INPUT)
+	if state.powerUpTimer == 0 {
+		t.Fatal("eating the power pellet didn't start a power-up")
+	}
+	updateUntilMoved(t, &state, RIGHT_INPUT)
+	if state.digCharges != DIG_CHARGES_PER_PICKUP {
+		t.Fatalf("dig charges = %d after the pickup, want %d", state.digCharges, DIG_CHARGES_PER_PICKUP)
+	}
+	updateUntilMoved(t, &state, RIGHT_INPUT|DIG_INPUT)
+	if state.level.walls[1][4] {
+		t.Fatal("the wall wasn't dug through")
+	}
+
+	rewind(&state)
+	if head := state.snakes[0].getHead(); head != (Vec2{x: 1, y: 1}) {
+		t.Errorf("head = %v after rewinding, want {1 1}", head)
+	}
+	if state.rewindCharges != REWIND_CHARGES-1 {
+		t.Errorf("rewind charges = %d, want %d", state.rewindCharges, REWIND_CHARGES-1)
+	}
+	// everything the three moves changed is undone together, so nothing can
+	// be collected twice
+	if state.powerUpTimer != 0 || state.level.foodAt(Vec2{x: 2, y: 1}) == -1 {
+		t.Errorf("power-up timer %d with pellet eaten %t, want the pellet back and no power-up", state.powerUpTimer, state.level.foodAt(Vec2{x: 2, y: 1}) == -1)
+	}
+	if state.digCharges != 0 || len(state.level.digPickups) != 1 {
+		t.Errorf("%d dig charges with %d pickups, want the pickup back and no charges", state.digCharges, len(state.level.digPickups))
+	}
+	if !state.level.walls[1][4] {
+		t.Error("the dug wall didn't come back")
+	}
+}
+
+func TestRewindHistoryIsACopy(t *testing.T) {
+	state := newTestState(t, "#######\n#SPD#F#\n#...E.#\n#######\n")
+	state.status = StatusPlaying
+	updateUntilMoved(t, &state, RIGHT_INPUT)
+	updateUntilMoved(t, &state, RIGHT_INPUT)
+	state.level.walls[2][1] = true
+	if state.history[0].level.walls[2][1] {
+		t.Error("a wall added after the snapshot shows up in it")
+	}
+}
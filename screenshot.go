@@ -0,0 +1,58 @@
+package main
+
+import (
+	"fmt"
+	"image"
+	"image/png"
+	"log"
+	"os"
+	"time"
+
+	"github.com/hajimehoshi/ebiten/v2"
+)
+
+const SCREENSHOT_TIME_FORMAT = "20060102-150405"
+
+// saveScreenshot encodes the screen to a timestamped PNG in the working
+// directory and returns the file name
+func saveScreenshot(screen *ebiten.Image) (name string, err error) {
+	// ebiten panics if pixels are read back at a point where the GPU can't
+	// provide them, so turn that into an error instead of crashing the game
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("reading screen: %v", r)
+		}
+	}()
+
+	bounds := screen.Bounds()
+	img := image.NewRGBA(bounds)
+	screen.ReadPixels(img.Pix)
+
+	name = "pacsnek-" + time.Now().Format(SCREENSHOT_TIME_FORMAT) + ".png"
+	file, err := os.Create(name)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+
+	if err := png.Encode(file, img); err != nil {
+		return "", fmt.Errorf("encoding %s: %w", name, err)
+	}
+	return name, file.Close()
+}
+
+// takeScreenshot saves the screen if one was requested during the last
+// update. a failed save is logged rather than interrupting the game.
+func (game *Game) takeScreenshot(screen *ebiten.Image) {
+	if !game.screenshotRequested {
+		return
+	}
+	game.screenshotRequested = false
+
+	name, err := saveScreenshot(screen)
+	if err != nil {
+		log.Printf("saving screenshot: %v", err)
+		return
+	}
+	log.Printf("saved screenshot to %s", name)
+}
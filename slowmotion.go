@@ -0,0 +1,54 @@
+package main
+
+import (
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/vector"
+)
+
+// SLOW_MOTION_FACTOR is how many times longer the snake waits between steps
+// while slow motion is active
+const SLOW_MOTION_FACTOR = 2
+
+// effectiveMoveInterval returns the number of frames the snake waits between
+// steps right now: its own interval, shortened the longer it has grown and
+// stretched while slow motion is active
+func (snake *Snake) effectiveMoveInterval(state *State) int {
+	interval := state.config.moveIntervalForLength(snake.moveInterval, len(snake.body))
+	if state.slowMotionTimer > 0 {
+		return interval * SLOW_MOTION_FACTOR
+	}
+	return interval
+}
+
+// updateSlowMotion counts down active slow motion by one frame, the same way
+// updatePowerUp does for power-ups
+func updateSlowMotion(state *State) {
+	if state.slowMotionTimer > 0 {
+		state.slowMotionTimer -= 1
+	}
+}
+
+// eatSlowPickup starts slow motion when the snake's head is on a slow-motion
+// tile
+func (snake *Snake) eatSlowPickup(state *State) {
+	for i, pickup := range state.level.slowPickups {
+		if snake.getHead() == pickup {
+			state.level.slowPickups = state.level.slowPickups.removeAt(i)
+			state.slowMotionTimer = state.config.slowMotionTime
+			state.playSound(SoundEat)
+			return
+		}
+	}
+}
+
+// drawSlowPickups draws each slow-motion tile as a ring, to set it apart from
+// the solid power pellets
+func drawSlowPickups(screen *ebiten.Image, state *State) {
+	size := float32(state.layout.cellSize)
+	for _, pickup := range state.level.slowPickups {
+		if isVisible(state, pickup) {
+			offset := viewportOffset(state, pickup)
+			vector.StrokeCircle(screen, (float32(offset.x)+0.5)*size, (float32(offset.y)+0.5)*size, size/3, 2, state.config.palette.powerUp, true)
+		}
+	}
+}
@@ -0,0 +1,35 @@
+package main
+
+import "testing"
+
+// framesUntilMoved steps the state until player one's snake has moved and
+// returns how many frames that took
+func framesUntilMoved(t *testing.T, state *State) int {
+	t.Helper()
+	frame := state.frame
+	stepUntilMoved(t, state, Vec2{})
+	return state.frame - frame
+}
+
+func TestSlowMotion(t *testing.T) {
+	state := newTestState(t, "###############\n#SZ..........E#\n#F............#\n###############\n")
+	state.config.slowMotionTime = 3 * state.config.moveInterval
+	stepUntilMoved(t, &state, Vec2{x: 1, y: 0})
+	if state.slowMotionTimer != state.config.slowMotionTime {
+		t.Fatalf("slow motion timer = %d after the pickup, want %d", state.slowMotionTimer, state.config.slowMotionTime)
+	}
+
+	slow := state.config.moveInterval * SLOW_MOTION_FACTOR
+	if frames := framesUntilMoved(t, &state); frames != slow {
+		t.Errorf("first step in slow motion took %d frames, want %d", frames, slow)
+	}
+	for i := 0; i < 10 && state.slowMotionTimer > 0; i++ {
+		framesUntilMoved(t, &state)
+	}
+	if state.slowMotionTimer != 0 {
+		t.Fatalf("slow motion timer = %d, want it run out", state.slowMotionTimer)
+	}
+	if frames := framesUntilMoved(t, &state); frames != state.config.moveInterval {
+		t.Errorf("step after slow motion took %d frames, want %d", frames, state.config.moveInterval)
+	}
+}
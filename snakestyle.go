@@ -0,0 +1,78 @@
+package main
+
+import (
+	"fmt"
+	"image/color"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/vector"
+)
+
+// SnakeStyle is how the snake's body is drawn
+type SnakeStyle int
+
+const (
+	// SnakeStyleSquares draws a square on each cell of the body, with a gap
+	// between each
+	SnakeStyleSquares SnakeStyle = iota
+	// SnakeStyleRounded draws the body as one rounded line through the middle
+	// of its cells, so turns look smooth
+	SnakeStyleRounded
+)
+
+func (style SnakeStyle) String() string {
+	switch style {
+	case SnakeStyleSquares:
+		return "squares"
+	case SnakeStyleRounded:
+		return "rounded"
+	}
+	return "unknown"
+}
+
+// parseSnakeStyle returns the style with the given name, as written by String
+func parseSnakeStyle(name string) (SnakeStyle, error) {
+	for style := SnakeStyleSquares; style <= SnakeStyleRounded; style++ {
+		if style.String() == name {
+			return style, nil
+		}
+	}
+	return SnakeStyleSquares, fmt.Errorf("unknown snake style %q", name)
+}
+
+// drawRoundedSnake draws the snake as capsules joining the middles of each
+// pair of neighbouring cells in its body, from the tail up so the segments
+// nearer the head are drawn on top. segments that are only neighbours by
+// wrapping around the level or through a portal are left unjoined, since
+// they're apart on screen. the head is drawn as a circle in its own color
+// when drawHead is set, and left for its sprite otherwise.
+func drawRoundedSnake(screen *ebiten.Image, state *State, snake *Snake, headColor color.RGBA, bodyColor color.RGBA, drawHead bool) {
+	size := float32(state.layout.cellSize)
+	// the same width as the squares drawn by drawCell
+	radius := (size - 1) / 2
+	center := func(offset Vec2) (float32, float32) {
+		return float32(offset.x)*size + radius, float32(offset.y)*size + radius
+	}
+
+	for i := len(snake.body) - 1; i >= 1; i-- {
+		p, next := snake.body[i], snake.body[i-1]
+		if !isVisible(state, p) && !isVisible(state, next) {
+			continue
+		}
+		from, to := viewportOffset(state, p), viewportOffset(state, next)
+		fromX, fromY := center(from)
+		if from.manhattan(to) == 1 {
+			toX, toY := center(to)
+			vector.StrokeLine(screen, fromX, fromY, toX, toY, radius*2, bodyColor, true)
+		}
+		if isVisible(state, p) {
+			vector.DrawFilledCircle(screen, fromX, fromY, radius, bodyColor, true)
+		}
+	}
+
+	head := snake.getHead()
+	if drawHead && isVisible(state, head) {
+		x, y := center(viewportOffset(state, head))
+		vector.DrawFilledCircle(screen, x, y, radius, headColor, true)
+	}
+}
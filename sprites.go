@@ -0,0 +1,74 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	"image/color"
+	_ "image/png"
+	"io/fs"
+	"log"
+	"math"
+
+	"github.com/hajimehoshi/ebiten/v2"
+)
+
+// Sprites holds the images drawn for food, the snake's head, and ghosts. the
+// images are white so they can be tinted with the palette, and any that are
+// missing are left nil and drawn as plain cells instead.
+type Sprites struct {
+	food *ebiten.Image
+	// head faces right, and is rotated to face the way the snake is going
+	head  *ebiten.Image
+	ghost *ebiten.Image
+}
+
+// NewSprites creates a new Sprites struct by loading the sprites from the
+// assets folder
+func NewSprites() Sprites {
+	return Sprites{
+		food:  loadSprite("assets/food.png"),
+		head:  loadSprite("assets/head.png"),
+		ghost: loadSprite("assets/ghost.png"),
+	}
+}
+
+// loadSprite decodes the named PNG from the assets folder. a missing file
+// returns nil quietly, and one that can't be decoded is logged and returns
+// nil, so either way the game falls back to drawing cells.
+func loadSprite(name string) *ebiten.Image {
+	content, err := assets.ReadFile(name)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		log.Printf("loading sprite: %v", err)
+		return nil
+	}
+
+	img, _, err := image.Decode(bytes.NewReader(content))
+	if err != nil {
+		log.Printf("loading sprite %s: %v", name, err)
+		return nil
+	}
+	return ebiten.NewImageFromImage(img)
+}
+
+// drawSprite draws sprite over the cell at p, tinted with c, scaled by scale
+// around the cell's center, and turned to face direction. a zero direction
+// leaves the sprite as drawn.
+func drawSprite(screen *ebiten.Image, state *State, sprite *ebiten.Image, p Vec2, c color.Color, scale float64, direction Vec2) {
+	bounds := sprite.Bounds()
+	size := float64(state.layout.cellSize - 1)
+	offset := viewportOffset(state, p)
+
+	op := &ebiten.DrawImageOptions{}
+	op.GeoM.Translate(-float64(bounds.Dx())/2, -float64(bounds.Dy())/2)
+	if direction != (Vec2{}) {
+		op.GeoM.Rotate(math.Atan2(float64(direction.y), float64(direction.x)))
+	}
+	op.GeoM.Scale(size/float64(bounds.Dx())*scale, size/float64(bounds.Dy())*scale)
+	op.GeoM.Translate(float64(offset.x*state.layout.cellSize)+size/2, float64(offset.y*state.layout.cellSize)+size/2)
+	op.ColorScale.ScaleWithColor(c)
+	screen.DrawImage(sprite, op)
+}
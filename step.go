@@ -0,0 +1,67 @@
+package main
+
+// update advances the game by one frame with the given input, one mask per
+// snake as returned by readInput. it holds all of the game logic run while
+// playing and nothing that reads the keyboard or draws, so it runs the same
+// live, in a replay, or headless through Step.
+func (state *State) update(input Slice[int]) {
+	state.frame++
+	updatePowerUp(state)
+	updateSlowMotion(state)
+	updateGates(state)
+	updateBonus(state)
+	updateSuddenDeath(state)
+	updateRewind(state, input)
+	handleInput(state, input)
+	for i := range state.snakes {
+		if state.status == StatusPlaying {
+			state.snakes[i].move(state)
+		}
+	}
+	if state.status == StatusPlaying {
+		moveGhosts(state)
+	}
+	if state.status == StatusPlaying {
+		updateTimeLimit(state)
+	}
+	recordRun(state)
+	updateCombo(state)
+	updatePopups(state)
+	if state.bannerFrames > 0 {
+		state.bannerFrames -= 1
+	}
+}
+
+// Step turns player one's snake toward direction and advances the game by one
+// frame, returning the status afterwards. a zero direction keeps the snake on
+// its current course. the snake only moves once every moveInterval frames, and
+// turns wait in its input queue until then, the same as key presses do.
+//
+// Step needs no window or audio, since sound effects are only queued in
+// pendingSounds, so it can drive a game from a test or a bot. a new State is
+// started by the first call. while the death animation plays Step ignores
+// direction and only counts it down, a completed level is left for the next
+// one on the following call, and once the game is won or lost Step leaves it
+// as it is.
+func (state *State) Step(direction Vec2) Status {
+	if state.status == StatusStarted {
+		state.status = StatusPlaying
+	}
+	if state.status == StatusDying {
+		updateDying(state)
+		return state.status
+	}
+	if state.status == StatusLevelComplete {
+		advanceLevel(state)
+		return state.status
+	}
+	if state.status != StatusPlaying {
+		return state.status
+	}
+
+	if direction != (Vec2{}) {
+		state.snakes[0].queueDirection(direction)
+	}
+	state.update(NewSlice[int]())
+	return state.status
+}
@@ -0,0 +1,176 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestStep(t *testing.T) {
+	tests := []struct {
+		name       string
+		levels     []string
+		lives      int
+		direction  Vec2
+		wantStatus Status
+		wantLives  int
+		wantSounds Slice[Sound]
+	}{
+		{
+			name:       "eat and leave the last level",
+			levels:     []string{"#####\n#SFE#\n#####\n"},
+			lives:      3,
+			direction:  Vec2{x: 1, y: 0},
+			wantStatus: StatusWon,
+			wantLives:  3,
+			wantSounds: NewSlice(SoundEat, SoundWin),
+		},
+		{
+			name:       "leave a level with another after it",
+			levels:     []string{"#####\n#SFE#\n#####\n", "#####\n#SFE#\n#####\n"},
+			lives:      3,
+			direction:  Vec2{x: 1, y: 0},
+			wantStatus: StatusLevelComplete,
+			wantLives:  3,
+			wantSounds: NewSlice(SoundEat),
+		},
+		{
+			name:       "crash with lives left",
+			levels:     []string{"#####\n#SFE#\n#####\n"},
+			lives:      3,
+			direction:  Vec2{x: 0, y: -1},
+			wantStatus: StatusPlaying,
+			wantLives:  2,
+			wantSounds: NewSlice(SoundDie),
+		},
+		{
+			name:       "crash on the last life",
+			levels:     []string{"#####\n#SFE#\n#####\n"},
+			lives:      1,
+			direction:  Vec2{x: 0, y: -1},
+			wantStatus: StatusDying,
+			wantLives:  0,
+			wantSounds: NewSlice(SoundDie),
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			config := DefaultConfig()
+			config.levels = testLevels(test.levels...)
+			config.startingLives = test.lives
+			state, err := NewState(1, config)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			status := state.Step(test.direction)
+			for i := 0; i < 50 && status == StatusPlaying && len(state.pendingSounds) < len(test.wantSounds); i++ {
+				status = state.Step(Vec2{})
+			}
+			// give the snake time to reach the exit after eating
+			for i := 0; i < 50 && status == StatusPlaying && test.wantStatus != StatusPlaying; i++ {
+				status = state.Step(Vec2{})
+			}
+			if status != test.wantStatus {
+				t.Errorf("status = %v, want %v", status, test.wantStatus)
+			}
+			if state.lives != test.wantLives {
+				t.Errorf("lives = %d, want %d", state.lives, test.wantLives)
+			}
+			if !reflect.DeepEqual(state.pendingSounds, test.wantSounds) {
+				t.Errorf("sounds = %v, want %v", state.pendingSounds, test.wantSounds)
+			}
+		})
+	}
+}
+
+func TestStepAfterTheGameEnds(t *testing.T) {
+	config := DefaultConfig()
+	config.levels = testLevels("#####\n#SFE#\n#####\n")
+	config.startingLives = 1
+	state, err := NewState(1, config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	state.Step(Vec2{x: 0, y: -1})
+	for i := 0; i < 1000 && state.status != StatusLost; i++ {
+		state.Step(Vec2{})
+	}
+	if state.status != StatusLost {
+		t.Fatalf("status = %v, want lost once the death animation ends", state.status)
+	}
+	head := state.snakes[0].getHead()
+	if status := state.Step(Vec2{x: 1, y: 0}); status != StatusLost || state.snakes[0].getHead() != head {
+		t.Errorf("a lost game changed: status %v, head %v, want lost at %v", status, state.snakes[0].getHead(), head)
+	}
+}
+
+// newZenState starts a zen game on the given level
+func newZenState(t *testing.T, level string) State {
+	t.Helper()
+	config := DefaultConfig()
+	config.levels = testLevels(level)
+	config.mode = ModeZen
+	state, err := NewState(1, config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return state
+}
+
+// stepUntil steps the state in direction, then keeps it on course, until
+// done reports true or limit frames have passed
+func stepUntil(state *State, direction Vec2, limit int, done func() bool) {
+	state.Step(direction)
+	for i := 0; i < limit && !done(); i++ {
+		state.Step(Vec2{})
+	}
+}
+
+func TestZenWallHit(t *testing.T) {
+	state := newZenState(t, "#######\n#S...E#\n#F#####\n#######\n")
+	lives := state.lives
+
+	stepUntil(&state, Vec2{x: 0, y: -1}, 200, func() bool { return false })
+	if state.status != StatusPlaying || state.lives != lives {
+		t.Errorf("status %v with %d lives after hitting a wall, want playing with %d", state.status, state.lives, lives)
+	}
+	if head := state.snakes[0].getHead(); head != (Vec2{x: 1, y: 1}) {
+		t.Errorf("head = %v, want it waiting at {1 1}", head)
+	}
+	// turning away from the wall still works
+	stepUntil(&state, Vec2{x: 1, y: 0}, 100, func() bool { return state.snakes[0].getHead() != Vec2{x: 1, y: 1} })
+	if head := state.snakes[0].getHead(); head != (Vec2{x: 2, y: 1}) {
+		t.Errorf("head = %v after turning, want {2 1}", head)
+	}
+}
+
+func TestZenBlockedKeepsHistory(t *testing.T) {
+	state := newZenState(t, "######\n#S.#E#\n#F...#\n######\n")
+	stepUntil(&state, Vec2{x: 1, y: 0}, 100, func() bool { return state.snakes[0].getHead() == Vec2{x: 2, y: 1} })
+	history := len(state.history)
+	if history == 0 {
+		t.Fatal("the step to the wall wasn't kept for rewinding")
+	}
+	for i := 0; i < 200; i++ {
+		state.Step(Vec2{})
+	}
+	if len(state.history) != history {
+		t.Errorf("history grew from %d to %d while blocked", history, len(state.history))
+	}
+}
+
+func TestZenDeadEndIsEscapable(t *testing.T) {
+	state := newZenState(t, "#######\n#S...E#\n###.###\n###F###\n#######\n")
+	stepUntil(&state, Vec2{x: 1, y: 0}, 100, func() bool { return state.snakes[0].getHead() == Vec2{x: 3, y: 1} })
+	stepUntil(&state, Vec2{x: 0, y: 1}, 100, func() bool { return state.snakes[0].getHead() == Vec2{x: 3, y: 3} })
+	if len(state.snakes[0].body) < 2 {
+		t.Fatalf("body = %v, want the snake to have grown into the dead end", state.snakes[0].body)
+	}
+
+	for i := 0; i < 1000 && state.snakes[0].getHead() != state.level.entrance; i++ {
+		state.Step(Vec2{})
+	}
+	if head := state.snakes[0].getHead(); head != state.level.entrance || state.status != StatusPlaying {
+		t.Errorf("head %v with status %v, want the snake back at the entrance and playing", head, state.status)
+	}
+}
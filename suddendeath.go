@@ -0,0 +1,87 @@
+package main
+
+import "github.com/hajimehoshi/ebiten/v2"
+
+const (
+	SUDDEN_DEATH_INTERVAL = 3 * FPS // frames between each new wall
+	SUDDEN_DEATH_WARNING  = FPS     // frames a cell flashes before it's walled
+)
+
+// updateSuddenDeath counts down to the next sudden death wall once the level
+// has gone on for config.suddenDeathTime frames. the cell to be walled is
+// picked SUDDEN_DEATH_WARNING frames early so it can flash, and when its time
+// comes it waits while a snake or ghost is on it, so nothing is walled in
+// without warning.
+func updateSuddenDeath(state *State) {
+	if state.config.suddenDeathTime <= 0 {
+		return
+	}
+	if state.suddenDeathTimer > 0 {
+		state.suddenDeathTimer -= 1
+	}
+	if state.suddenDeathTimer > SUDDEN_DEATH_WARNING {
+		return
+	}
+	if !state.suddenDeathPending {
+		cell, ok := nextSuddenDeathCell(state)
+		if !ok {
+			return
+		}
+		state.suddenDeathCell = cell
+		state.suddenDeathPending = true
+	}
+	if state.suddenDeathTimer > 0 || isOccupied(state, state.suddenDeathCell) {
+		return
+	}
+	state.level.walls[state.suddenDeathCell.y][state.suddenDeathCell.x] = true
+	state.invalidateWalls()
+	state.suddenDeathPending = false
+	state.suddenDeathTimer = SUDDEN_DEATH_INTERVAL
+}
+
+// nextSuddenDeathCell returns the open cell closest to the level's edge that
+// has nothing on it, taking cells at the same distance in grid order. the
+// entrance is never walled, so the snake always has somewhere to respawn. ok
+// is false once there are no such cells left.
+func nextSuddenDeathCell(state *State) (cell Vec2, ok bool) {
+	level := &state.level
+	occupied := occupiedCells(state)
+	occupied[level.entrance] = true
+
+	best := -1
+	for y := 0; y < level.height; y++ {
+		for x := 0; x < level.width; x++ {
+			position := Vec2{x: x, y: y}
+			if level.walls[y][x] || occupied[position] || level.ghostAt(position) {
+				continue
+			}
+			ring := level.ringOf(position)
+			if best == -1 || ring < best {
+				best = ring
+				cell = position
+			}
+		}
+	}
+	return cell, best != -1
+}
+
+// ringOf returns how many cells position is from the nearest edge of the level
+func (level *Level) ringOf(position Vec2) int {
+	ring := position.x
+	for _, distance := range [3]int{position.y, level.width - 1 - position.x, level.height - 1 - position.y} {
+		if distance < ring {
+			ring = distance
+		}
+	}
+	return ring
+}
+
+// drawSuddenDeath flashes the cell that is about to be walled
+func drawSuddenDeath(screen *ebiten.Image, state *State) {
+	if !state.suddenDeathPending || !isVisible(state, state.suddenDeathCell) {
+		return
+	}
+	if state.frame%10 < 5 {
+		drawCell(screen, state, state.suddenDeathCell, dimColor(state.config.palette.wall, 0.5))
+	}
+}
@@ -0,0 +1,58 @@
+package main
+
+import "testing"
+
+// newSuddenDeathState starts a game on level that starts walling itself in
+// after the given number of frames
+func newSuddenDeathState(t *testing.T, level string, frames int) State {
+	t.Helper()
+	config := DefaultConfig()
+	config.levels = testLevels(level)
+	config.suddenDeathTime = frames
+	state, err := NewState(1, config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return state
+}
+
+func TestSuddenDeathSchedule(t *testing.T) {
+	state := newSuddenDeathState(t, "#######\n#S...E#\n#F....#\n#######\n", 2*FPS)
+	// the entrance is never walled, so the first cells go along the top row
+	// next to it
+	for i, cell := range []Vec2{{x: 2, y: 1}, {x: 3, y: 1}} {
+		frames := 2 * FPS
+		if i > 0 {
+			frames = SUDDEN_DEATH_INTERVAL
+		}
+		for frame := 1; frame < frames; frame++ {
+			updateSuddenDeath(&state)
+			if state.level.walls[cell.y][cell.x] {
+				t.Fatalf("%v walled %d frames early", cell, frames-frame)
+			}
+		}
+		updateSuddenDeath(&state)
+		if !state.level.walls[cell.y][cell.x] {
+			t.Fatalf("%v still open after %d frames", cell, frames)
+		}
+	}
+}
+
+func TestSuddenDeathKills(t *testing.T) {
+	state := newSuddenDeathState(t, "#######\n#S...E#\n#F....#\n#######\n", FPS)
+	lives := state.lives
+	for i := 0; i < 1000 && !state.level.walls[1][2]; i++ {
+		state.Step(Vec2{})
+	}
+	if !state.level.walls[1][2] {
+		t.Fatal("{2 1} was never walled")
+	}
+
+	state.Step(Vec2{x: 1, y: 0})
+	for i := 0; i < 100 && state.lives == lives; i++ {
+		state.Step(Vec2{})
+	}
+	if state.lives != lives-1 {
+		t.Errorf("lives = %d, want one lost to the new wall at {2 1}", state.lives)
+	}
+}
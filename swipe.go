@@ -0,0 +1,86 @@
+package main
+
+import "github.com/hajimehoshi/ebiten/v2"
+
+// SWIPE_THRESHOLD is how many pixels a touch or mouse drag has to travel
+// before it turns the snake
+const SWIPE_THRESHOLD = 30
+
+// Swipe turns touch and mouse drags into directions. a drag that has turned
+// the snake starts measuring again from where it is, so one long drag can
+// steer around several corners without lifting.
+type Swipe struct {
+	// active is set while a touch or the left mouse button is held, and
+	// startX and startY are where the drag is measured from
+	active bool
+	startX int
+	startY int
+}
+
+// swipeDirection returns the direction of a drag by the given number of
+// pixels along its dominant axis, or the zero vector if it hasn't gone
+// SWIPE_THRESHOLD pixels along either axis or is exactly diagonal
+func swipeDirection(dx int, dy int) Vec2 {
+	absX, absY := abs(dx), abs(dy)
+	if absX < SWIPE_THRESHOLD && absY < SWIPE_THRESHOLD {
+		return Vec2{}
+	}
+	return dominantDirection(dx, dy)
+}
+
+// dominantDirection returns the direction along whichever axis the given
+// offset is longer in, or the zero vector if it's exactly diagonal
+func dominantDirection(dx int, dy int) Vec2 {
+	absX, absY := abs(dx), abs(dy)
+	switch {
+	case absX > absY && dx > 0:
+		return Vec2{x: 1, y: 0}
+	case absX > absY:
+		return Vec2{x: -1, y: 0}
+	case absY > absX && dy > 0:
+		return Vec2{x: 0, y: 1}
+	case absY > absX:
+		return Vec2{x: 0, y: -1}
+	}
+	return Vec2{}
+}
+
+// pointerPosition returns where the first touch is, or the cursor while the
+// left mouse button is held. ok is false when neither is down.
+func pointerPosition() (x int, y int, ok bool) {
+	if touches := ebiten.AppendTouchIDs(nil); len(touches) > 0 {
+		x, y = ebiten.TouchPosition(touches[0])
+		return x, y, true
+	}
+	if ebiten.IsMouseButtonPressed(ebiten.MouseButtonLeft) {
+		x, y = ebiten.CursorPosition()
+		return x, y, true
+	}
+	return 0, 0, false
+}
+
+// update follows the drag in progress and returns an input mask with the bit
+// for its direction set once it has gone far enough, or 0 otherwise. the
+// mask goes through handleInput like a held key, so it's subject to the same
+// guard against reversing.
+func (swipe *Swipe) update() int {
+	x, y, ok := pointerPosition()
+	if !ok {
+		swipe.active = false
+		return 0
+	}
+	if !swipe.active {
+		swipe.active = true
+		swipe.startX, swipe.startY = x, y
+		return 0
+	}
+
+	direction := swipeDirection(x-swipe.startX, y-swipe.startY)
+	for i, d := range directions {
+		if d == direction {
+			swipe.startX, swipe.startY = x, y
+			return 1 << i
+		}
+	}
+	return 0
+}
@@ -0,0 +1,35 @@
+package main
+
+import "testing"
+
+func TestSwipeDirection(t *testing.T) {
+	tests := []struct {
+		name string
+		dx   int
+		dy   int
+		want Vec2
+	}{
+		{"no movement", 0, 0, Vec2{}},
+		{"just short right", SWIPE_THRESHOLD - 1, 0, Vec2{}},
+		{"just short up", 0, -(SWIPE_THRESHOLD - 1), Vec2{}},
+		{"short diagonal", SWIPE_THRESHOLD - 1, SWIPE_THRESHOLD - 1, Vec2{}},
+		{"right at the threshold", SWIPE_THRESHOLD, 0, Vec2{x: 1, y: 0}},
+		{"left at the threshold", -SWIPE_THRESHOLD, 0, Vec2{x: -1, y: 0}},
+		{"down at the threshold", 0, SWIPE_THRESHOLD, Vec2{x: 0, y: 1}},
+		{"up at the threshold", 0, -SWIPE_THRESHOLD, Vec2{x: 0, y: -1}},
+		{"mostly right", 50, -40, Vec2{x: 1, y: 0}},
+		{"mostly left", -50, 40, Vec2{x: -1, y: 0}},
+		{"mostly down", 40, 50, Vec2{x: 0, y: 1}},
+		{"mostly up", -40, -50, Vec2{x: 0, y: -1}},
+		{"slightly off a short axis", 10, SWIPE_THRESHOLD, Vec2{x: 0, y: 1}},
+		{"exact diagonal", 40, 40, Vec2{}},
+		{"exact opposite diagonal", -40, 40, Vec2{}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := swipeDirection(test.dx, test.dy); got != test.want {
+				t.Errorf("swipeDirection(%d, %d) = %v, want %v", test.dx, test.dy, got, test.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,97 @@
+package main
+
+import (
+	"image"
+	"image/color"
+
+	"github.com/hajimehoshi/ebiten/v2"
+)
+
+// WallLayer is every wall of a level drawn once to an offscreen image. walls
+// never move during play, so each frame copies the visible part of the layer
+// instead of drawing each wall again.
+type WallLayer struct {
+	image *ebiten.Image
+	// the layer is only reused while these still match what would be drawn
+	levelID  int
+	cellSize int
+	color    color.RGBA
+}
+
+// NewWallLayer draws the level's walls to a new image with one cell per
+// cellSize pixels
+func NewWallLayer(level *Level, cellSize int, c color.RGBA) *WallLayer {
+	layer := &WallLayer{
+		image:    ebiten.NewImage(level.width*cellSize, level.height*cellSize),
+		levelID:  level.id,
+		cellSize: cellSize,
+		color:    c,
+	}
+	for y := 0; y < level.height; y++ {
+		for x := 0; x < level.width; x++ {
+			if level.walls[y][x] && !level.gateAt(Vec2{x: x, y: y}) {
+				drawWall(layer.image, level, Vec2{x: x, y: y}, float32(cellSize), c)
+			}
+		}
+	}
+	return layer
+}
+
+// walls returns the state's wall layer, drawing a new one if the level, cell
+// size, or wall color has changed since the last one was drawn
+func (state *State) walls() *WallLayer {
+	layer := state.wallLayer
+	if layer != nil && layer.levelID == state.level.id && layer.cellSize == state.layout.cellSize && layer.color == state.config.palette.wall {
+		return layer
+	}
+	if layer != nil {
+		layer.image.Deallocate()
+	}
+	state.wallLayer = NewWallLayer(&state.level, state.layout.cellSize, state.config.palette.wall)
+	return state.wallLayer
+}
+
+// invalidateWalls drops the cached wall layer and minimap after the level's
+// walls change, so they're drawn again on the next frame
+func (state *State) invalidateWalls() {
+	if state.wallLayer != nil {
+		state.wallLayer.image.Deallocate()
+		state.wallLayer = nil
+	}
+	if state.minimapCache != nil {
+		state.minimapCache.image.Deallocate()
+		state.minimapCache = nil
+	}
+}
+
+// drawWallLayer copies the part of the wall layer under the viewport to the
+// screen. axes where the level is at least as big as the viewport wrap around,
+// matching viewportOffset, so the layer is drawn a second time just past the
+// level's far edge to fill in the cells that wrapped.
+func drawWallLayer(screen *ebiten.Image, state *State) {
+	layer := state.walls()
+	size := state.layout.cellSize
+
+	// clip to whole cells so wrapped walls don't show in the leftover pixels
+	// along the right and bottom of the screen
+	bounds := image.Rect(0, 0, state.layout.viewportWidth()*size, state.layout.viewportHeight()*size)
+	viewport := screen.SubImage(bounds).(*ebiten.Image)
+
+	margin := viewportMargin(state)
+	lefts := NewSlice((margin.x - state.viewportX) * size)
+	if state.level.width >= state.layout.viewportWidth() {
+		lefts = append(lefts, (state.level.width-state.viewportX)*size)
+	}
+	tops := NewSlice((margin.y - state.viewportY) * size)
+	if state.level.height >= state.layout.viewportHeight() {
+		tops = append(tops, (state.level.height-state.viewportY)*size)
+	}
+
+	for _, top := range tops {
+		for _, left := range lefts {
+			op := &ebiten.DrawImageOptions{}
+			op.GeoM.Translate(float64(left), float64(top))
+			viewport.DrawImage(layer.image, op)
+		}
+	}
+}